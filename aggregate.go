@@ -0,0 +1,66 @@
+package runner
+
+import (
+	"errors"
+	"sort"
+)
+
+// WithErrorAggregator 设置多个任务出错时Start返回错误的合并方式，默认为JoinAggregator
+// errs的key为任务id，没有任务出错时不会调用fn，fn返回nil时Start返回nil，fn中的panic会被捕获，此时使用JoinAggregator
+func WithErrorAggregator(fn func(errs map[int]error) error) Option {
+	return func(r *Runner) {
+		r.aggregator = fn
+	}
+}
+
+// JoinAggregator 按照任务id从小到大的顺序，将所有任务的错误通过errors.Join合并为一个error
+func JoinAggregator(errs map[int]error) error {
+	ids := sortedIDs(errs)
+	joined := make([]error, 0, len(ids))
+	for _, k := range ids {
+		joined = append(joined, errs[k])
+	}
+
+	return errors.Join(joined...)
+}
+
+// FirstErrorAggregator 只返回任务id最小的任务的错误，没有任务出错时返回nil
+func FirstErrorAggregator(errs map[int]error) error {
+	ids := sortedIDs(errs)
+	if len(ids) == 0 {
+		return nil
+	}
+
+	return errs[ids[0]]
+}
+
+// sortedIDs 获取errs中的任务id，从小到大排序
+func sortedIDs(errs map[int]error) []int {
+	ids := make([]int, 0, len(errs))
+	for k := range errs {
+		ids = append(ids, k)
+	}
+
+	sort.Ints(ids)
+	return ids
+}
+
+// aggregateErrors 通过r.aggregator将errs合并为一个error
+func (r *Runner) aggregateErrors(errs map[int]error) error {
+	if r.aggregator == nil {
+		return JoinAggregator(errs)
+	}
+
+	var err error
+	ok := false
+	r.safeCall("error aggregator", func() {
+		err = r.aggregator(errs)
+		ok = true
+	})
+
+	if !ok {
+		return JoinAggregator(errs)
+	}
+
+	return err
+}
@@ -0,0 +1,52 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// TestRunnerErrorAggregator test custom aggregation of task errors
+func TestRunnerErrorAggregator(t *testing.T) {
+	errFirst, errSecond := errors.New("first"), errors.New("second")
+	add := func(p *Runner) {
+		p.Add(func() error { return nil }, func() error { return errFirst }, func() error { return errSecond })
+	}
+
+	p := New(WithSilent())
+	add(p)
+	if err := p.Start(); !errors.Is(err, errFirst) || !errors.Is(err, errSecond) {
+		t.Fatalf("expected joined errors, got: %v", err)
+	}
+
+	p = New(WithSilent(), WithErrorAggregator(FirstErrorAggregator))
+	add(p)
+	if err := p.Start(); err != errFirst {
+		t.Fatalf("expected first error, got: %v", err)
+	}
+
+	p = New(WithSilent(), WithErrorAggregator(func(errs map[int]error) error {
+		return fmt.Errorf("%d tasks failed", len(errs))
+	}))
+	add(p)
+	if err := p.Start(); err == nil || err.Error() != "2 tasks failed" {
+		t.Fatalf("expected count summary, got: %v", err)
+	}
+
+	// 合并函数panic时使用JoinAggregator
+	p = New(WithSilent(), WithErrorAggregator(func(errs map[int]error) error { panic("boom") }))
+	add(p)
+	if err := p.Start(); !errors.Is(err, errFirst) || !errors.Is(err, errSecond) {
+		t.Fatalf("expected joined errors, got: %v", err)
+	}
+}
+
+// TestRunnerNilAggregateThreshold test the error threshold with an aggregator returning nil
+func TestRunnerNilAggregateThreshold(t *testing.T) {
+	p := New(WithSilent(), WithErrorThreshold(0), WithErrorAggregator(func(errs map[int]error) error { return nil }))
+	p.Add(func() error { return errors.New("failed") })
+
+	if err := p.Start(); err != ErrTooManyErrors {
+		t.Fatalf("expected ErrTooManyErrors, got: %v", err)
+	}
+}
@@ -0,0 +1,121 @@
+package runner
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrNoTasks runner has no tasks
+var ErrNoTasks = errors.New("no tasks to run")
+
+// ErrAllSkipped all tasks were skipped and none of them succeeded
+var ErrAllSkipped = errors.New("all tasks skipped")
+
+// RunAny 并发执行所有的任务，返回第一个执行成功的任务id，并通过ctx取消其他还在执行的任务
+// 只有所有的任务都执行失败时，才会返回按照任务id排序合并之后的错误，此时返回的任务id为-1
+// 适用于有多个等价的策略，只需要其中一个成功的场景，其他任务需要感知ctx的取消才能及时退出
+// 任务之间的依赖关系会被忽略，条件不满足被跳过的任务不会被视为执行成功，所有的任务都被跳过时返回ErrAllSkipped
+// 和Start一样调用WithFinalizer、WithOnFinish以及OnComplete的回调函数并监听系统退出信号，接收到信号或者调用Stop时立即取消所有任务的ctx并返回InterruptError，不等待WithGracefulShutdown
+func (r *Runner) RunAny(ctx context.Context) (id int, err error) {
+	if !r.state.CompareAndSwap(int32(StateIdle), int32(StateRunning)) &&
+		!r.state.CompareAndSwap(int32(StateDone), int32(StateRunning)) {
+		return -1, ErrRunning
+	}
+	defer r.state.Store(int32(StateDone))
+	defer func() {
+		r.finalize(err)
+	}()
+
+	gen := r.resetState()
+	defer r.clearStop()
+	defer func() {
+		r.setOutcome(err)
+		r.closeProgress()
+		r.publish(gen, Result{TaskID: -1, Err: err, Duration: r.TotalDuration()})
+	}()
+	defer r.markEnd()
+
+	r.mu.Lock()
+	// 依赖关系被忽略，丢弃上一次执行残留的依赖状态，避免任务结束时重复通知
+	r.depStates = nil
+	tasks := make([]task, len(r.tasks))
+	for k, t := range r.tasks {
+		t.deps = nil
+		tasks[k] = t
+	}
+	r.mu.Unlock()
+
+	if len(tasks) == 0 {
+		return -1, ErrNoTasks
+	}
+
+	defer r.notifySignals()()
+
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	// 监听中断信号，RunAny不区分第一次和第二次接收到的信号
+	interrupted := r.interruptedCh()
+	watchDone := make(chan struct{})
+	defer close(watchDone)
+	taskCtx, cancelTasks := context.WithCancelCause(ctx)
+	defer cancelTasks(nil)
+	go r.watchInterrupt(interrupted, make(chan struct{}), watchDone, cancelTasks)
+
+	if r.isInterrupt() {
+		r.log(LevelInfo, "run any interrupted before start")
+		return -1, r.interruptErr()
+	}
+
+	results := make(chan Result, len(tasks))
+	for k, t := range tasks {
+		go func(k int, t task) {
+			results <- Result{TaskID: k, Err: r.runTask(taskCtx, gen, k, t)}
+		}(k, t)
+	}
+
+	for i := 0; i < len(tasks); i++ {
+		select {
+		case res := <-results:
+			if res.Err == nil && !r.isSkipped(res.TaskID) {
+				// 其他任务的执行结果不再记录
+				r.setLastTaskId(gen, res.TaskID)
+				r.freeze(gen)
+				r.log(LevelInfo, "run any task succeeded", "task_id", r.logIDAt(res.TaskID))
+				return res.TaskID, nil
+			}
+		case <-ctx.Done():
+			r.freeze(gen)
+			if isRunTimeout(ctx) {
+				r.log(LevelWarn, ErrRunTimeout.Error())
+				return -1, ErrRunTimeout
+			}
+
+			return -1, ctx.Err()
+		case <-interrupted:
+			r.freeze(gen)
+			err = r.interruptErr()
+			cancelTasks(err)
+			r.log(LevelInfo, "run any interrupted", "error", err)
+			return -1, err
+		}
+	}
+
+	err = r.joinErrors()
+	if err == nil {
+		r.log(LevelInfo, "run any all tasks skipped")
+		return -1, ErrAllSkipped
+	}
+
+	r.log(LevelInfo, "run any all tasks failed", "error", err)
+	return -1, err
+}
+
+// isSkipped 判断任务是否因为条件不满足被跳过
+func (r *Runner) isSkipped(k int) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, ok := r.skipped[k]
+	return ok
+}
@@ -0,0 +1,160 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestRunAny test the first succeeded task wins and cancels the others
+func TestRunAny(t *testing.T) {
+	r := New(WithSilent())
+	r.AddCtx(func(ctx context.Context) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Second):
+			return nil
+		}
+	}, func(ctx context.Context) error {
+		return errors.New("task 1 failed")
+	}, func(ctx context.Context) error {
+		time.Sleep(10 * time.Millisecond)
+		return nil
+	})
+
+	id, err := r.RunAny(context.Background())
+	if err != nil || id != 2 {
+		t.Fatalf("RunAny = %d, %v, want 2, nil", id, err)
+	}
+
+	if r.GetLastTaskId() != 2 {
+		t.Fatalf("last task id = %d, want 2", r.GetLastTaskId())
+	}
+}
+
+// TestRunAnyAllFailed test joined errors when all tasks failed
+func TestRunAnyAllFailed(t *testing.T) {
+	errA := errors.New("a")
+	errB := errors.New("b")
+	r := New(WithSilent())
+	r.Add(func() error { return errA }, func() error { return errB })
+	r.AddConditional(func() bool { return false }, func() error { return nil })
+
+	id, err := r.RunAny(context.Background())
+	if id != -1 || !errors.Is(err, errA) || !errors.Is(err, errB) {
+		t.Fatalf("RunAny = %d, %v", id, err)
+	}
+
+	if _, err := New(WithSilent()).RunAny(context.Background()); !errors.Is(err, ErrNoTasks) {
+		t.Fatalf("empty runner err = %v, want ErrNoTasks", err)
+	}
+}
+
+// TestRunAnyAfterDependencies test RunAny after a Start with dependencies
+func TestRunAnyAfterDependencies(t *testing.T) {
+	r := New(WithSilent())
+	r.AddDependent("a", nil, func() error { return nil })
+	r.AddDependent("b", []string{"a"}, func() error { return nil })
+
+	if err := r.Start(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := r.RunAny(context.Background()); err != nil {
+		t.Fatalf("RunAny err = %v", err)
+	}
+
+	// 等待其他任务结束，确保没有重复关闭依赖状态
+	time.Sleep(10 * time.Millisecond)
+}
+
+// TestRunAnyAllSkipped test an error is returned when every task is skipped
+func TestRunAnyAllSkipped(t *testing.T) {
+	r := New(WithSilent())
+	r.AddConditional(func() bool { return false }, func() error { return nil })
+	r.AddConditional(func() bool { return false }, func() error { return nil })
+
+	if id, err := r.RunAny(context.Background()); id != -1 || !errors.Is(err, ErrAllSkipped) {
+		t.Fatalf("RunAny = %d, %v, want -1, ErrAllSkipped", id, err)
+	}
+}
+
+// TestRunAnyStop test Stop interrupts RunAny and cancels the running tasks
+func TestRunAnyStop(t *testing.T) {
+	r := New(WithSilent(), WithNoSignals())
+	canceled := make(chan error, 1)
+	r.AddCtx(func(ctx context.Context) error {
+		r.Stop()
+		<-ctx.Done()
+		canceled <- context.Cause(ctx)
+		return ctx.Err()
+	})
+
+	if id, err := r.RunAny(context.Background()); id != -1 || !errors.Is(err, ErrInterrupt) {
+		t.Fatalf("RunAny = %d, %v, want -1, ErrInterrupt", id, err)
+	}
+
+	select {
+	case cause := <-canceled:
+		if !errors.Is(cause, ErrStopped) {
+			t.Fatalf("unexpected cause: %v", cause)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected task ctx canceled")
+	}
+
+	// Start之前调用了Stop，不会执行任何任务
+	r = New(WithSilent(), WithNoSignals())
+	executed := false
+	r.Add(func() error {
+		executed = true
+		return nil
+	})
+	r.Stop()
+
+	if _, err := r.RunAny(context.Background()); !errors.Is(err, ErrInterrupt) || executed {
+		t.Fatalf("expected interrupted before start, got: %v %v", err, executed)
+	}
+}
+
+// TestRunAnyFinalize test RunAny calls the finalizer, finish callback and subscribers like Start
+func TestRunAnyFinalize(t *testing.T) {
+	var (
+		finalErr  error
+		finalized int
+		rep       RunReport
+		events    []int
+	)
+
+	progress := make(chan Progress, 4)
+	r := New(WithSilent(), WithNoSignals(), WithProgress(progress), WithFinalizer(func(err error) {
+		finalized++
+		finalErr = err
+	}), WithOnFinish(func(report RunReport) {
+		rep = report
+	}))
+	r.OnComplete(func(res Result) {
+		if res.TaskID == -1 {
+			events = append(events, res.TaskID)
+		}
+	})
+	r.AddCtx(func(ctx context.Context) error {
+		r.Stop()
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	_, err := r.RunAny(context.Background())
+	if !errors.Is(err, ErrInterrupt) || finalized != 1 || finalErr != err {
+		t.Fatalf("unexpected finalizer call: %d %v %v", finalized, finalErr, err)
+	}
+
+	if !rep.Interrupted || !r.Interrupted() || len(events) != 1 {
+		t.Fatalf("unexpected outcome: %+v %v %v", rep, r.Interrupted(), events)
+	}
+
+	for range progress {
+	}
+}
@@ -0,0 +1,83 @@
+package runner
+
+// asyncRun 一次通过StartAsync异步执行的结果
+type asyncRun struct {
+	started bool          // 是否已经开始执行
+	done    chan struct{} // 执行结束后关闭
+	err     error         // Start返回的错误，done关闭之后才可以读取
+}
+
+// StartAsync 在独立的goroutine中执行所有的任务，然后立即返回，通过Wait等待执行结束
+// 上一次异步执行还没有结束时返回ErrRunning
+func (r *Runner) StartAsync() error {
+	r.mu.Lock()
+	a := r.pendingAsync()
+	if a.started {
+		r.mu.Unlock()
+		return ErrRunning
+	}
+
+	a.started = true
+	r.mu.Unlock()
+
+	go func() {
+		defer close(a.done)
+		a.err = r.Start()
+	}()
+
+	return nil
+}
+
+// Wait 阻塞直到StartAsync启动的执行结束，返回与Start相同的错误
+// 可以在多个goroutine中同时调用，每个调用方都会得到相同的结果
+// 在StartAsync之前调用时，会等待下一次异步执行结束
+func (r *Runner) Wait() error {
+	r.mu.Lock()
+	a := r.currentAsync()
+	r.mu.Unlock()
+
+	<-a.done
+	return a.err
+}
+
+// currentAsync 获取最近一次异步执行，还没有异步执行时创建一个等待下一次执行的asyncRun，调用方需要持有r.mu
+func (r *Runner) currentAsync() *asyncRun {
+	if r.async == nil {
+		r.async = &asyncRun{done: make(chan struct{})}
+	}
+
+	return r.async
+}
+
+// pendingAsync 获取下一次异步执行，最近一次异步执行已经结束时创建新的asyncRun，调用方需要持有r.mu
+func (r *Runner) pendingAsync() *asyncRun {
+	a := r.currentAsync()
+	select {
+	case <-a.done:
+		r.async = &asyncRun{done: make(chan struct{})}
+	default:
+	}
+
+	return r.async
+}
+
+// StartChan 在独立的goroutine中执行所有的任务，返回的通道会接收到与Start相同的错误，然后被关闭
+// 方便调用方在select中同时监听执行结果和其他通道
+func (r *Runner) StartChan() <-chan error {
+	ch := make(chan error, 1)
+	go func() {
+		defer close(ch)
+		ch <- r.Start()
+	}()
+
+	return ch
+}
+
+// Done 返回StartAsync启动的执行结束时关闭的通道，方便和其他通道一起在select中等待
+// 在StartAsync之前调用时，返回的通道会在下一次异步执行结束之后关闭，通过Start同步执行时不会关闭
+func (r *Runner) Done() <-chan struct{} {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.currentAsync().done
+}
@@ -0,0 +1,100 @@
+package runner
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestRunnerStartAsync test start in background and wait from multiple goroutines
+func TestRunnerStartAsync(t *testing.T) {
+	errTask := errors.New("task failed")
+	release := make(chan struct{})
+
+	p := New()
+	p.Add(func() error {
+		<-release
+		return errTask
+	})
+
+	if err := p.StartAsync(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := p.StartAsync(); err != ErrRunning {
+		t.Fatalf("expected ErrRunning, got: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, 3)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = p.Wait()
+		}(i)
+	}
+
+	close(release)
+	wg.Wait()
+
+	for i, err := range errs {
+		if !errors.Is(err, errTask) {
+			t.Fatalf("waiter %d expected errTask, got: %v", i, err)
+		}
+	}
+
+	// 执行结束之后调用Wait，直接返回最近一次的结果
+	if err := p.Wait(); !errors.Is(err, errTask) {
+		t.Fatalf("expected errTask, got: %v", err)
+	}
+}
+
+// TestRunnerStartChan test select on the completion channel
+func TestRunnerStartChan(t *testing.T) {
+	errTask := errors.New("task failed")
+	p := New(WithSilent())
+	p.Add(func() error { return errTask })
+
+	select {
+	case err := <-p.StartChan():
+		if !errors.Is(err, errTask) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("StartChan did not complete")
+	}
+}
+
+// TestRunnerDone test Done closes after the next async run
+func TestRunnerDone(t *testing.T) {
+	p := New(WithSilent())
+	release := make(chan struct{})
+	p.Add(func() error {
+		<-release
+		return nil
+	})
+
+	done := p.Done()
+	if err := p.StartAsync(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	select {
+	case <-done:
+		t.Fatal("expected done open while running")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(release)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected done closed after run")
+	}
+
+	if p.Done() != done {
+		t.Fatal("expected Done to return the finished run channel")
+	}
+}
@@ -0,0 +1,60 @@
+package runner
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// BackoffFunc 根据已经执行的次数attempt(从1开始)，计算下一次重试之前需要等待的时间
+type BackoffFunc func(attempt int) time.Duration
+
+// ConstantBackoff 每次重试之前都等待固定的时间d
+func ConstantBackoff(d time.Duration) BackoffFunc {
+	return func(attempt int) time.Duration {
+		return d
+	}
+}
+
+// ExponentialBackoff 指数退避，第attempt次重试之前等待 base * factor^(attempt-1)
+// 结果超过time.Duration的范围时返回最大的time.Duration，结果小于0时返回0
+func ExponentialBackoff(base time.Duration, factor float64) BackoffFunc {
+	return func(attempt int) time.Duration {
+		if attempt < 1 {
+			attempt = 1
+		}
+
+		d := float64(base) * math.Pow(factor, float64(attempt-1))
+		switch {
+		case d >= math.MaxInt64: // float64(math.MaxInt64)为2^63，转换为int64会溢出
+			return math.MaxInt64
+		case d > 0:
+			return time.Duration(d)
+		default: // 包括NaN
+			return 0
+		}
+	}
+}
+
+// JitteredBackoff 带有随机抖动的指数退避，等待时间在[0, min(max, base*2^(attempt-1))]之间随机
+// 避免大量任务同时重试造成的惊群效应
+func JitteredBackoff(base, max time.Duration) BackoffFunc {
+	exp := ExponentialBackoff(base, 2)
+	return func(attempt int) time.Duration {
+		d := exp(attempt)
+		if d > max || d <= 0 {
+			d = max
+		}
+
+		if d <= 0 {
+			return 0
+		}
+
+		// d为最大的time.Duration时，加1会溢出
+		if d == math.MaxInt64 {
+			return time.Duration(rand.Int63())
+		}
+
+		return time.Duration(rand.Int63n(int64(d) + 1))
+	}
+}
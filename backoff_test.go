@@ -0,0 +1,73 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+// TestExponentialBackoff test exponential backoff duration
+func TestExponentialBackoff(t *testing.T) {
+	backoff := ExponentialBackoff(10*time.Millisecond, 2)
+	for attempt, expected := range map[int]time.Duration{
+		1: 10 * time.Millisecond,
+		2: 20 * time.Millisecond,
+		3: 40 * time.Millisecond,
+	} {
+		if d := backoff(attempt); d != expected {
+			t.Fatalf("attempt %d expected %v, got: %v", attempt, expected, d)
+		}
+	}
+}
+
+// TestJitteredBackoff test jittered backoff never exceed max
+func TestJitteredBackoff(t *testing.T) {
+	backoff := JitteredBackoff(10*time.Millisecond, 50*time.Millisecond)
+	for attempt := 1; attempt < 20; attempt++ {
+		if d := backoff(attempt); d < 0 || d > 50*time.Millisecond {
+			t.Fatalf("attempt %d backoff out of range: %v", attempt, d)
+		}
+	}
+}
+
+// TestBackoffOverflow test backoff durations are clamped instead of overflowing
+func TestBackoffOverflow(t *testing.T) {
+	if d := ExponentialBackoff(time.Second, 2)(40); d != math.MaxInt64 {
+		t.Fatalf("expected max duration, got: %v", d)
+	}
+
+	if d := ExponentialBackoff(-time.Second, 2)(3); d != 0 {
+		t.Fatalf("expected zero duration, got: %v", d)
+	}
+
+	backoff := JitteredBackoff(time.Second, math.MaxInt64)
+	for _, attempt := range []int{1, 40, 100, 2000} {
+		if d := backoff(attempt); d < 0 {
+			t.Fatalf("unexpected jittered duration for attempt %d: %v", attempt, d)
+		}
+	}
+}
+
+// TestRunnerBackoffStrategy test backoff sleep respect context cancel
+func TestRunnerBackoffStrategy(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	p := New(WithRetry(3, 0), WithBackoffStrategy(ConstantBackoff(time.Hour)))
+	p.Add(func() error {
+		cancel()
+		return errors.New("task failed")
+	})
+
+	start := time.Now()
+	_ = p.StartContext(ctx)
+	if time.Since(start) > time.Second {
+		t.Fatal("backoff sleep not canceled by context")
+	}
+
+	if attempts := p.GetAttempts(); attempts[0] != 1 {
+		t.Fatalf("expected 1 attempt, got: %v", attempts)
+	}
+}
@@ -0,0 +1,43 @@
+package runner
+
+// WithChunking 设置按照size个任务一批的方式执行r.tasks，每一批任务都获取之后，释放上一批任务的函数引用，让GC可以回收任务闭包
+// 适用于任务数量非常多、任务闭包引用了大量内存并且只需要执行一次的场景
+// 注意：已经释放的任务再次执行时会记录ErrNilTask，因此设置之后不能再次调用Start执行同样的任务，需要重新添加任务
+// 只对r.tasks中的任务生效，StartStream从通道中获取的任务执行之后本身就不会被runner引用
+// 每个任务的执行结果依然会被记录，GetResults等占用的内存和任务数量成正比，GetAllErrors只占用出错任务的内存
+func WithChunking(size int) Option {
+	return func(r *Runner) {
+		r.chunkSize = size
+	}
+}
+
+// releaseChunk 即将获取本次执行中第k个执行的任务时，释放上一批已经获取过的任务，调用方需要持有r.mu
+// k超出r.tasks的范围时表示所有的任务都已经获取过，释放剩余的所有任务
+func (r *Runner) releaseChunk(k int) {
+	if r.chunkSize <= 0 {
+		return
+	}
+
+	end := k >= r.runLenLocked()
+	if end {
+		k = r.runLenLocked()
+	}
+
+	n := k - r.from
+	if n <= 0 || (!end && n%r.chunkSize != 0) {
+		return
+	}
+
+	size := n % r.chunkSize
+	if size == 0 {
+		size = r.chunkSize
+	}
+
+	for i := k - size; i < k; i++ {
+		if id := r.taskIDLocked(i); id >= 0 && id < len(r.tasks) {
+			r.tasks[id].fn = nil
+			r.tasks[id].cond = nil
+			r.tasks[id].raw = nil
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package runner
+
+import (
+	"errors"
+	"runtime"
+	"testing"
+)
+
+// TestRunnerChunking test task funcs released after each chunk
+func TestRunnerChunking(t *testing.T) {
+	for _, concurrency := range []int{1, 3} {
+		p := New(WithSilent(), WithChunking(3), WithConcurrency(concurrency))
+		executed := 0
+		for i := 0; i < 10; i++ {
+			p.Add(func() error {
+				p.mu.Lock()
+				executed++
+				p.mu.Unlock()
+				return nil
+			})
+		}
+
+		if err := p.Start(); err != nil || executed != 10 {
+			t.Fatalf("unexpected result: %v %d", err, executed)
+		}
+
+		for k, task := range p.tasks {
+			if task.fn != nil {
+				t.Fatalf("expected task %d released", k)
+			}
+		}
+
+		// 已经释放的任务再次执行时记录ErrNilTask
+		if err := p.Start(); !errors.Is(err, ErrNilTask) {
+			t.Fatalf("expected ErrNilTask, got: %v", err)
+		}
+	}
+}
+
+// TestRunnerLazyErrors test GetAllErrors without any failed task
+func TestRunnerLazyErrors(t *testing.T) {
+	p := New(WithSilent())
+	p.Add(func() error { return nil })
+	_ = p.Start()
+
+	if errs := p.GetAllErrors(); errs == nil || len(errs) != 0 {
+		t.Fatalf("expected empty errors, got: %v", errs)
+	}
+}
+
+// benchmarkRunner 执行20000个都执行成功的任务，retained-B/op为Start返回之后runner仍然占用的堆内存
+func benchmarkRunner(b *testing.B, opts ...Option) {
+	b.ReportAllocs()
+	var retained uint64
+	for i := 0; i < b.N; i++ {
+		var before, after runtime.MemStats
+		runtime.GC()
+		runtime.ReadMemStats(&before)
+
+		p := New(append([]Option{WithSilent()}, opts...)...)
+		for k := 0; k < 20000; k++ {
+			buf := make([]byte, 64)
+			p.Add(func() error {
+				buf[0]++
+				return nil
+			})
+		}
+
+		_ = p.Start()
+
+		runtime.GC()
+		runtime.ReadMemStats(&after)
+		if after.HeapAlloc > before.HeapAlloc {
+			retained += after.HeapAlloc - before.HeapAlloc
+		}
+		runtime.KeepAlive(p)
+	}
+
+	b.ReportMetric(float64(retained)/float64(b.N), "retained-B/op")
+}
+
+// BenchmarkRunner 20000个都执行成功的任务
+func BenchmarkRunner(b *testing.B) {
+	benchmarkRunner(b)
+}
+
+// BenchmarkRunnerChunking 20000个都执行成功的任务，每1000个任务释放一次
+func BenchmarkRunnerChunking(b *testing.B) {
+	benchmarkRunner(b, WithChunking(1000))
+}
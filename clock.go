@@ -0,0 +1,85 @@
+package runner
+
+import (
+	"context"
+	"time"
+)
+
+// Clock 时间的抽象，runner中的超时、任务间隔、重试退避以及重复执行的等待都通过Clock计时
+// 测试中可以通过WithClock注入可以手动推进的时钟，不需要真实的等待就可以确定性地触发超时
+type Clock interface {
+	Now() time.Time                         // 获取当前时间
+	After(d time.Duration) <-chan time.Time // 返回d时长之后触发的通道
+}
+
+// realClock 基于time包的真实时钟，没有设置WithClock时使用
+type realClock struct{}
+
+// Now 实现Clock接口
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// After 实现Clock接口
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// WithClock 设置runner使用的时钟，默认使用真实的时钟，c为nil时忽略
+// WithTimeoutWarning以及WithGracefulShutdown依然使用真实的时钟，WithRateLimit的限流使用该时钟
+func WithClock(c Clock) Option {
+	return func(r *Runner) {
+		if c != nil {
+			r.clock = c
+		}
+	}
+}
+
+// isRealClock 是否使用真实的时钟，使用真实的时钟时可以直接使用time.Timer以及context.WithTimeout
+func (r *Runner) isRealClock() bool {
+	_, ok := r.clock.(realClock)
+	return ok || r.clock == nil
+}
+
+// now 获取runner时钟的当前时间
+func (r *Runner) now() time.Time {
+	if r.isRealClock() {
+		return time.Now()
+	}
+
+	return r.clock.Now()
+}
+
+// since 获取从t开始经过的时间
+func (r *Runner) since(t time.Time) time.Duration {
+	return r.now().Sub(t)
+}
+
+// after 返回d时长之后触发的通道以及停止计时的函数，使用真实的时钟时停止之后会及时释放timer
+func (r *Runner) after(d time.Duration) (<-chan time.Time, func()) {
+	if r.isRealClock() {
+		timer := time.NewTimer(d)
+		return timer.C, func() { timer.Stop() }
+	}
+
+	return r.clock.After(d), func() {}
+}
+
+// withClockTimeout 派生出一个通过r.clock计时的context，超时之后以ErrRunTimeout为cause取消
+func (r *Runner) withClockTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	ch, stop := r.after(d)
+	go func() {
+		defer stop()
+
+		select {
+		case <-ch:
+			cancel(ErrRunTimeout)
+		case <-ctx.Done():
+		}
+	}()
+
+	return ctx, func() {
+		cancel(context.Canceled)
+	}
+}
@@ -0,0 +1,158 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock 可以手动推进的时钟
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []fakeWaiter
+}
+
+// fakeWaiter 等待时钟推进到at的通道
+type fakeWaiter struct {
+	at time.Time
+	ch chan time.Time
+}
+
+// Now 实现Clock接口
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+// After 实现Clock接口
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ch := make(chan time.Time, 1)
+	c.waiters = append(c.waiters, fakeWaiter{at: c.now.Add(d), ch: ch})
+	return ch
+}
+
+// Advance 将时钟推进d时长，触发所有已经到期的通道
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+	waiters := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.at.After(c.now) {
+			waiters = append(waiters, w)
+			continue
+		}
+
+		w.ch <- c.now
+	}
+
+	c.waiters = waiters
+}
+
+// waitWaiter 等待有goroutine在时钟上等待
+func (c *fakeClock) waitWaiter() {
+	for {
+		c.mu.Lock()
+		n := len(c.waiters)
+		c.mu.Unlock()
+		if n > 0 {
+			return
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// TestRunnerClockTimeout test run timeout fired by a fake clock without real waiting
+func TestRunnerClockTimeout(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	p := New(WithSilent(), WithNoSignals(), WithClock(clock), WithTimeout(time.Hour))
+
+	started := make(chan struct{})
+	p.AddCtx(func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	go func() {
+		<-started
+		clock.Advance(time.Hour)
+	}()
+
+	begin := time.Now()
+	if err := p.Start(); !errors.Is(err, ErrRunTimeout) {
+		t.Fatalf("expected ErrRunTimeout, got: %v", err)
+	}
+
+	if time.Since(begin) > time.Second || p.Elapsed() != time.Hour {
+		t.Fatalf("unexpected elapsed: %v %v", time.Since(begin), p.Elapsed())
+	}
+}
+
+// TestRunnerClockTaskTimeout test task timeout and interval use the fake clock
+func TestRunnerClockTaskTimeout(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	p := New(WithSilent(), WithNoSignals(), WithClock(clock), WithTaskTimeout(time.Minute))
+
+	started := make(chan struct{})
+	p.AddCtx(func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	go func() {
+		<-started
+		clock.Advance(time.Minute)
+	}()
+
+	if err := p.Start(); !errors.Is(err, ErrTaskTimeout) {
+		t.Fatalf("expected ErrTaskTimeout, got: %v", err)
+	}
+
+	if d := p.Results()[0].Duration; d != time.Minute {
+		t.Fatalf("unexpected task duration: %v", d)
+	}
+}
+
+// TestRunnerClockRateLimit test the rate limiter produces and waits tokens on the fake clock
+func TestRunnerClockRateLimit(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	p := New(WithSilent(), WithNoSignals(), WithClock(clock), WithRateLimit(1, 1))
+	// 每个任务都将时钟推进1s，下一个任务不需要等待就可以获取到令牌
+	for i := 0; i < 2; i++ {
+		p.Add(func() error {
+			clock.Advance(time.Second)
+			return nil
+		})
+	}
+
+	// 第3个任务使用推进时钟产生的令牌，第4个任务需要在时钟上等待1s
+	p.Add(func() error { return nil }, func() error { return nil })
+	go func() {
+		clock.waitWaiter()
+		clock.Advance(time.Second)
+	}()
+
+	done := make(chan error, 1)
+	go func() { done <- p.Start() }()
+
+	select {
+	case err := <-done:
+		if err != nil || p.Elapsed() != 3*time.Second {
+			t.Fatalf("unexpected result: %v %v", err, p.Elapsed())
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected rate limit driven by the fake clock")
+	}
+}
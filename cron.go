@@ -0,0 +1,26 @@
+package runner
+
+// Job 返回可以直接注册到cron调度器的func()，例如 c.AddFunc("@every 1m", r.Job())
+// 每次调度时调用Start执行所有的任务，Start返回的错误会被记录到日志中
+// 上一次调度还没有执行结束时，本次调度返回ErrRunning，不会重复执行
+func (r *Runner) Job() func() {
+	return r.CronFunc(nil)
+}
+
+// CronFunc 和Job一样返回可以注册到cron调度器的func()，Start返回错误时调用onError
+// onError为nil时只记录日志，onError中的panic会被捕获并记录日志
+func (r *Runner) CronFunc(onError func(err error)) func() {
+	return func() {
+		err := r.Start()
+		if err == nil {
+			return
+		}
+
+		r.log(LevelError, "cron job failed", "error", err)
+		if onError != nil {
+			r.safeCall("cron error handler", func() {
+				onError(err)
+			})
+		}
+	}
+}
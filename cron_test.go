@@ -0,0 +1,33 @@
+package runner
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestRunnerJob test cron adapters run all tasks and report errors
+func TestRunnerJob(t *testing.T) {
+	errTask := errors.New("task failed")
+	executed := 0
+	p := New(WithSilent())
+	p.Add(func() error {
+		executed++
+		return errTask
+	})
+
+	p.Job()()
+	if executed != 1 {
+		t.Fatalf("expected job to run tasks, executed: %d", executed)
+	}
+
+	var got error
+	job := p.CronFunc(func(err error) {
+		got = err
+		panic("error handler panic")
+	})
+	job()
+
+	if executed != 2 || !errors.Is(got, errTask) {
+		t.Fatalf("expected error handler called, executed: %d err: %v", executed, got)
+	}
+}
@@ -0,0 +1,225 @@
+package runner
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrDependencyCycle task dependencies contain a cycle
+	ErrDependencyCycle = errors.New("task dependency cycle")
+
+	// ErrUnknownDependency task depends on an unknown task id
+	ErrUnknownDependency = errors.New("unknown task dependency")
+
+	// ErrDuplicateTaskID task id is duplicated
+	ErrDuplicateTaskID = errors.New("duplicate task id")
+)
+
+// depState 一个带有id的任务在本次执行中的状态
+type depState struct {
+	done   chan struct{} // 任务执行结束后关闭
+	failed bool          // 任务是否没有执行成功，包括出错以及被跳过
+}
+
+// AddDependent 添加一个id为id的任务，该任务会在deps中的所有任务执行成功之后才会执行
+// Start之前会按照依赖关系对r.tasks进行一次拓扑排序，因此GetAllErrors等返回的任务index为排序之后的执行顺序
+// 依赖存在环、依赖了不存在的任务或者id重复时，Start不会执行任何任务，直接返回对应的错误
+// 依赖的任务出错或者被跳过时，该任务也会被跳过
+// 设置了WithConcurrency时，互相没有依赖关系的任务会并发执行
+func (r *Runner) AddDependent(id string, deps []string, fn func() error) {
+	r.addTasks(task{name: id, key: id, deps: deps, fn: wrapTask(fn), raw: fn})
+}
+
+// AddWithID 添加一个带有固定id的任务，id不会随着任务添加的顺序变化，可以通过GetAllErrorsByID获取该任务的错误
+// id和AddDependent的id是同一个命名空间，其他任务可以依赖该任务，id重复时Start返回ErrDuplicateTaskID
+func (r *Runner) AddWithID(id string, fn func() error) {
+	r.AddDependent(id, nil, fn)
+}
+
+// GetAllErrorsByID 获取已经完成任务的error，key为任务id，没有id的任务使用任务index或者WithIDFunc返回的id作为key
+func (r *Runner) GetAllErrorsByID() map[string]error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	errs := make(map[string]error, len(r.allErrors))
+	for k, err := range r.allErrors {
+		key := r.formatID(k, task{})
+		if k < len(r.tasks) {
+			key = r.formatID(k, r.tasks[k])
+			if r.tasks[k].key != "" {
+				key = r.tasks[k].key
+			}
+		}
+
+		errs[key] = err
+	}
+
+	return errs
+}
+
+// prepareDependencies 按照依赖关系对r.tasks进行拓扑排序，并初始化本次执行中每个任务的依赖状态
+// 没有依赖关系的任务保持原来的顺序
+func (r *Runner) prepareDependencies() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.depStates = nil
+
+	sorted, keys, err := r.sortDependencies(r.tasks)
+	if err != nil || sorted == nil {
+		return err
+	}
+
+	r.tasks = sorted
+	r.depStates = make(map[string]*depState, len(keys))
+	for key := range keys {
+		r.depStates[key] = &depState{done: make(chan struct{})}
+	}
+
+	return nil
+}
+
+// sortDependencies 按照依赖关系对tasks进行拓扑排序，不会修改tasks
+// 没有任何依赖关系时返回的sorted为nil，keys为任务id到排序之前index的映射
+func (r *Runner) sortDependencies(tasks []task) (sorted []task, keys map[string]int, err error) {
+	keys = make(map[string]int, len(tasks))
+	hasDeps := false
+	for k, t := range tasks {
+		if t.key == "" {
+			continue
+		}
+
+		if _, ok := keys[t.key]; ok {
+			return nil, nil, fmt.Errorf("%w: %s", ErrDuplicateTaskID, t.key)
+		}
+
+		keys[t.key] = k
+		hasDeps = hasDeps || len(t.deps) > 0
+	}
+
+	if !hasDeps {
+		return nil, keys, nil
+	}
+
+	// 按照index从小到大的Kahn算法进行拓扑排序
+	indegree := make([]int, len(tasks))
+	dependents := make(map[int][]int, len(keys))
+	for k, t := range tasks {
+		for _, dep := range t.deps {
+			d, ok := keys[dep]
+			if !ok {
+				return nil, nil, fmt.Errorf("%w: %s depends on %s", ErrUnknownDependency, r.nameOf(k, t), dep)
+			}
+
+			indegree[k]++
+			dependents[d] = append(dependents[d], k)
+		}
+	}
+
+	ready := &intHeap{}
+	for k := range tasks {
+		if indegree[k] == 0 {
+			heap.Push(ready, k)
+		}
+	}
+
+	sorted = make([]task, 0, len(tasks))
+	for ready.Len() > 0 {
+		k := heap.Pop(ready).(int)
+		sorted = append(sorted, tasks[k])
+		for _, d := range dependents[k] {
+			if indegree[d]--; indegree[d] == 0 {
+				heap.Push(ready, d)
+			}
+		}
+	}
+
+	if len(sorted) != len(tasks) {
+		cycle := make([]string, 0)
+		for k, t := range tasks {
+			if indegree[k] > 0 {
+				cycle = append(cycle, r.nameOf(k, t))
+			}
+		}
+
+		return nil, nil, fmt.Errorf("%w: %s", ErrDependencyCycle, strings.Join(cycle, ","))
+	}
+
+	return sorted, keys, nil
+}
+
+// waitDependencies 等待任务t依赖的所有任务执行结束，返回是否所有依赖的任务都执行成功
+// 返回的字符串为第一个没有执行成功的依赖任务id
+func (r *Runner) waitDependencies(ctx context.Context, t task) (string, bool) {
+	for _, dep := range t.deps {
+		st := r.dependency(dep)
+		if st == nil {
+			continue
+		}
+
+		select {
+		case <-st.done:
+		case <-ctx.Done():
+			return dep, false
+		}
+
+		r.mu.Lock()
+		failed := st.failed
+		r.mu.Unlock()
+
+		if failed {
+			return dep, false
+		}
+	}
+
+	return "", true
+}
+
+// dependency 获取id为key的任务在本次执行中的依赖状态
+func (r *Runner) dependency(key string) *depState {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.depStates[key]
+}
+
+// markDependency 任务t执行结束后，通知依赖该任务的其他任务
+func (r *Runner) markDependency(gen uint64, t task, failed bool) {
+	if t.key == "" {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	st := r.depStates[t.key]
+	if gen != r.gen || st == nil {
+		return
+	}
+
+	st.failed = failed
+	close(st.done)
+}
+
+// intHeap 任务index的最小堆
+type intHeap []int
+
+func (h intHeap) Len() int { return len(h) }
+
+func (h intHeap) Less(i, j int) bool { return h[i] < h[j] }
+
+func (h intHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *intHeap) Push(x interface{}) { *h = append(*h, x.(int)) }
+
+func (h *intHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[:n-1]
+	return x
+}
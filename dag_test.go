@@ -0,0 +1,116 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// TestRunnerAddDependent test run tasks in dependency order
+func TestRunnerAddDependent(t *testing.T) {
+	for _, n := range []int{1, 4} {
+		var (
+			mu    sync.Mutex
+			order []string
+		)
+
+		record := func(id string) func() error {
+			return func() error {
+				mu.Lock()
+				order = append(order, id)
+				mu.Unlock()
+				return nil
+			}
+		}
+
+		p := New(WithConcurrency(n))
+		p.AddDependent("c", []string{"a", "b"}, record("c"))
+		p.AddDependent("a", nil, record("a"))
+		p.AddDependent("b", []string{"a"}, record("b"))
+		p.Add(record("free"))
+
+		if err := p.Start(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		pos := make(map[string]int)
+		for i, id := range order {
+			pos[id] = i
+		}
+
+		if len(order) != 4 || pos["a"] > pos["b"] || pos["b"] > pos["c"] {
+			t.Fatalf("concurrency %d unexpected order: %v", n, order)
+		}
+	}
+}
+
+// TestRunnerDependencyErrors test cycle and unknown dependencies
+func TestRunnerDependencyErrors(t *testing.T) {
+	executed := false
+	fn := func() error {
+		executed = true
+		return nil
+	}
+
+	p := New()
+	p.AddDependent("a", []string{"b"}, fn)
+	p.AddDependent("b", []string{"a"}, fn)
+	if err := p.Start(); !errors.Is(err, ErrDependencyCycle) {
+		t.Fatalf("expected ErrDependencyCycle, got: %v", err)
+	}
+
+	p = New()
+	p.AddDependent("a", []string{"missing"}, fn)
+	if err := p.Start(); !errors.Is(err, ErrUnknownDependency) {
+		t.Fatalf("expected ErrUnknownDependency, got: %v", err)
+	}
+
+	if executed {
+		t.Fatal("expected no task executed")
+	}
+}
+
+// TestRunnerDependencyFailed test skip dependents of a failed task
+func TestRunnerDependencyFailed(t *testing.T) {
+	p := New()
+	p.AddDependent("a", nil, func() error { return errors.New("a failed") })
+	p.AddDependent("b", []string{"a"}, func() error { return nil })
+	p.AddDependent("c", []string{"b"}, func() error { return nil })
+
+	_ = p.Start()
+
+	if skipped := p.GetSkipped(); fmt.Sprint(skipped) != "[1 2]" {
+		t.Fatalf("unexpected skipped: %v", skipped)
+	}
+}
+
+// TestRunnerAddWithID test errors keyed by stable task ids
+func TestRunnerAddWithID(t *testing.T) {
+	build := func(order []string) map[string]error {
+		p := New(WithSilent())
+		for _, id := range order {
+			id := id
+			p.AddWithID(id, func() error { return errors.New(id + " failed") })
+		}
+
+		p.Add(func() error { return errors.New("anonymous") })
+		_ = p.Start()
+		return p.GetAllErrorsByID()
+	}
+
+	for _, order := range [][]string{{"build", "deploy"}, {"deploy", "build"}} {
+		errs := build(order)
+		if len(errs) != 3 || errs["build"].Error() != "build failed" || errs["deploy"].Error() != "deploy failed" ||
+			errs["2"].Error() != "anonymous" {
+			t.Fatalf("unexpected errors by id: %v", errs)
+		}
+	}
+
+	p := New(WithSilent())
+	p.AddWithID("a", func() error { return nil })
+	p.AddWithID("a", func() error { return nil })
+	if err := p.Start(); !errors.Is(err, ErrDuplicateTaskID) {
+		t.Fatalf("expected ErrDuplicateTaskID, got: %v", err)
+	}
+}
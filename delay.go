@@ -0,0 +1,63 @@
+package runner
+
+import (
+	"context"
+	"time"
+)
+
+// WithStartDelay 设置Start等待d之后再开始执行第一个任务，适用于需要等待数据库等依赖就绪的场景
+// 等待期间接收到中断信号或者调用Stop时Start立即返回ErrInterrupt，ctx结束时返回对应的错误
+// 等待的时间默认计入WithTimeout的超时时间，超时之后返回ErrRunTimeout，不计入时可以同时设置WithStartDelayExcludedFromTimeout
+// 设置了WithRepeat时，每一次执行之前都会等待
+func WithStartDelay(d time.Duration) Option {
+	return func(r *Runner) {
+		r.startDelay = d
+	}
+}
+
+// WithStartDelayExcludedFromTimeout 设置WithStartDelay的等待时间不计入WithTimeout的超时时间，等待结束之后才开始计时
+func WithStartDelayExcludedFromTimeout() Option {
+	return func(r *Runner) {
+		r.delayExcluded = true
+	}
+}
+
+// waitStartDelay 设置了WithStartDelay时，在开始执行第一个任务之前等待r.startDelay
+// excluded表示本次调用是否处于超时计时之前，只有和r.delayExcluded一致时才会等待
+func (r *Runner) waitStartDelay(ctx context.Context, excluded bool) error {
+	if r.startDelay <= 0 || excluded != r.delayExcluded {
+		return nil
+	}
+
+	r.log(LevelInfo, "wait before start", "delay", r.startDelay)
+	err := r.waitDelay(ctx, r.startDelay)
+	if err != nil && isRunTimeout(ctx) {
+		r.log(LevelWarn, ErrRunTimeout.Error())
+		return ErrRunTimeout
+	}
+
+	return err
+}
+
+// waitDelay 等待d时长，等待过程中接收到中断信号或者ctx结束时返回对应的错误
+func (r *Runner) waitDelay(ctx context.Context, d time.Duration) error {
+	timeCh, stop := r.after(d)
+	defer stop()
+
+	select {
+	case <-timeCh:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-r.interruptedCh(): // Start之前已经调用了Stop
+		return r.interruptErr()
+	case sg := <-r.interrupt:
+		r.log(LevelInfo, "received signal", "signal", sg.String())
+		r.mu.Lock()
+		r.signal = sg
+		r.mu.Unlock()
+
+		r.notifyInterrupt(sg)
+		return r.interruptErr()
+	}
+}
@@ -0,0 +1,67 @@
+package runner
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestRunnerStartDelay test the first task starts after the delay
+func TestRunnerStartDelay(t *testing.T) {
+	var startedAt time.Time
+	p := New(WithSilent(), WithStartDelay(30*time.Millisecond))
+	p.Add(func() error {
+		startedAt = time.Now()
+		return nil
+	})
+
+	begin := time.Now()
+	if err := p.Start(); err != nil || startedAt.Sub(begin) < 30*time.Millisecond {
+		t.Fatalf("unexpected result: %v %v", err, startedAt.Sub(begin))
+	}
+}
+
+// TestRunnerStartDelayTimeout test the delay counts against the timeout unless excluded
+func TestRunnerStartDelayTimeout(t *testing.T) {
+	executed := false
+	p := New(WithSilent(), WithStartDelay(100*time.Millisecond), WithTimeout(30*time.Millisecond))
+	p.Add(func() error {
+		executed = true
+		return nil
+	})
+
+	if err := p.Start(); !errors.Is(err, ErrRunTimeout) || executed {
+		t.Fatalf("expected ErrRunTimeout without running tasks, got: %v %v", err, executed)
+	}
+
+	p = New(WithSilent(), WithStartDelay(50*time.Millisecond), WithTimeout(30*time.Millisecond),
+		WithStartDelayExcludedFromTimeout())
+	p.Add(func() error {
+		executed = true
+		return nil
+	})
+
+	if err := p.Start(); err != nil || !executed {
+		t.Fatalf("unexpected result: %v %v", err, executed)
+	}
+}
+
+// TestRunnerStartDelayStop test Stop during the delay returns immediately
+func TestRunnerStartDelayStop(t *testing.T) {
+	p := New(WithSilent(), WithNoSignals(), WithStartDelay(time.Hour))
+	p.Add(func() error { return nil })
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		p.Stop()
+	}()
+
+	begin := time.Now()
+	if err := p.Start(); !errors.Is(err, ErrInterrupt) || time.Since(begin) > time.Second {
+		t.Fatalf("expected ErrInterrupt, got: %v", err)
+	}
+
+	if len(p.Results()) != 0 {
+		t.Fatalf("expected no tasks executed, got: %v", p.Results())
+	}
+}
@@ -0,0 +1,79 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+)
+
+// GroupError 任务组中的任务出错时记录的错误，errors.Is和errors.As可以匹配到组内任务以及rollback返回的错误
+type GroupError struct {
+	Group       string // 任务组名称
+	Index       int    // 组内出错的任务index
+	Err         error  // 组内任务返回的错误
+	RollbackErr error  // rollback返回的错误，没有设置rollback或者rollback执行成功时为nil
+}
+
+// Error 实现error接口
+func (e *GroupError) Error() string {
+	msg := fmt.Sprintf("group %s task %d failed: %v", e.Group, e.Index, e.Err)
+	if e.RollbackErr != nil {
+		msg += fmt.Sprintf(", rollback failed: %v", e.RollbackErr)
+	}
+
+	return msg
+}
+
+// Unwrap 返回组内任务以及rollback返回的错误
+func (e *GroupError) Unwrap() []error {
+	if e.RollbackErr == nil {
+		return []error{e.Err}
+	}
+
+	return []error{e.Err, e.RollbackErr}
+}
+
+// AddGroup 将一组任务作为一个整体添加到r.tasks队列中，组内的任务按照顺序依次执行，整个组只占用一个任务id
+// 组内任意一个任务出错、panic或者ctx结束时，后续的组内任务不再执行，调用rollback进行补偿，整个组视为执行失败
+// 组的执行结果以name作为任务名称记录，可以通过GetNamedErrors获取，出错时记录的错误为*GroupError
+// rollback为nil时不进行补偿，rollback中的panic会被捕获并记录在GroupError.RollbackErr中
+func (r *Runner) AddGroup(name string, tasks []func() error, rollback func() error) {
+	members := make([]func(ctx context.Context) (interface{}, error), 0, len(tasks))
+	for _, fn := range tasks {
+		members = append(members, wrapTask(fn))
+	}
+
+	r.addTasks(task{name: name, fn: r.groupTask(name, members, wrapTask(rollback))})
+}
+
+// groupTask 将一组任务适配为一个任务执行的func
+func (r *Runner) groupTask(name string, members []func(ctx context.Context) (interface{}, error),
+	rollback func(ctx context.Context) (interface{}, error)) func(ctx context.Context) (interface{}, error) {
+	return func(ctx context.Context) (interface{}, error) {
+		for i, fn := range members {
+			err := ctx.Err()
+			if err == nil && fn == nil {
+				err = ErrNilTask
+			}
+
+			if err == nil {
+				_, err = r.execTask(ctx, fn)
+			}
+
+			if err == nil {
+				continue
+			}
+
+			gerr := &GroupError{Group: name, Index: i, Err: err}
+			if rollback != nil {
+				r.log(LevelWarn, "task group failed, rollback", "group", name, "index", i, "error", err)
+				if _, rerr := r.execTask(ctx, rollback); rerr != nil {
+					gerr.RollbackErr = rerr
+				}
+			}
+
+			return nil, gerr
+		}
+
+		return nil, nil
+	}
+}
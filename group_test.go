@@ -0,0 +1,45 @@
+package runner
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestRunnerGroup test a failed member stops the group and runs rollback
+func TestRunnerGroup(t *testing.T) {
+	errStep := errors.New("step failed")
+	var steps []string
+	rolledBack := 0
+
+	p := New(WithSilent())
+	p.AddGroup("ok", []func() error{
+		func() error { steps = append(steps, "ok-0"); return nil },
+	}, func() error { rolledBack++; return nil })
+	p.AddGroup("order", []func() error{
+		func() error { steps = append(steps, "order-0"); return nil },
+		func() error { steps = append(steps, "order-1"); return errStep },
+		func() error { steps = append(steps, "order-2"); return nil },
+	}, func() error { rolledBack++; return nil })
+	p.AddGroup("panic", []func() error{
+		func() error { panic("boom") },
+	}, func() error { return errors.New("rollback failed") })
+
+	if err := p.Start(); !errors.Is(err, errStep) {
+		t.Fatalf("expected step error, got: %v", err)
+	}
+
+	if len(steps) != 3 || steps[2] != "order-1" || rolledBack != 1 {
+		t.Fatalf("unexpected steps %v or rollbacks %d", steps, rolledBack)
+	}
+
+	errs := p.GetNamedErrors()
+	var gerr *GroupError
+	if errs["ok"] != nil || !errors.As(errs["order"], &gerr) || gerr.Index != 1 || gerr.RollbackErr != nil {
+		t.Fatalf("unexpected group errors: %v", errs)
+	}
+
+	var pe *PanicError
+	if !errors.As(errs["panic"], &gerr) || !errors.As(gerr.Err, &pe) || gerr.RollbackErr == nil {
+		t.Fatalf("unexpected panic group error: %v", errs["panic"])
+	}
+}
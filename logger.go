@@ -0,0 +1,241 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+	"strconv"
+	"strings"
+)
+
+// Level 日志级别
+type Level int
+
+const (
+	// LevelDebug 调试日志，例如每个任务开始执行的日志
+	LevelDebug Level = iota
+	// LevelInfo 普通日志，例如所有任务执行结束的汇总日志
+	LevelInfo
+	// LevelWarn 警告日志，例如任务超时
+	LevelWarn
+	// LevelError 错误日志，例如任务返回错误或者panic
+	LevelError
+)
+
+// String 返回日志级别的名称
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "DEBUG"
+	case LevelInfo:
+		return "INFO"
+	case LevelWarn:
+		return "WARN"
+	case LevelError:
+		return "ERROR"
+	default:
+		return "LEVEL(" + strconv.Itoa(int(l)) + ")"
+	}
+}
+
+// LeveledLogger 支持日志级别的日志接口，keyvals为交替出现的key-value对
+// 设置的logger实现了该接口时，runner会优先通过LogAt输出日志
+type LeveledLogger interface {
+	LogAt(level Level, msg string, keyvals ...interface{})
+}
+
+// StructuredLogger 支持结构化字段的日志接口，keyvals为交替出现的key-value对
+// 设置的logger实现了该接口时，runner会优先通过Log输出日志
+type StructuredLogger interface {
+	Log(msg string, keyvals ...interface{})
+}
+
+// SlogLogger 将*slog.Logger适配为Logger，每一条日志都会输出为带有task_id、error等字段的结构化日志
+type SlogLogger struct {
+	logger *slog.Logger
+}
+
+// NewSlogLogger 创建一个slog适配器，l为nil时使用slog.Default()
+func NewSlogLogger(l *slog.Logger) *SlogLogger {
+	if l == nil {
+		l = slog.Default()
+	}
+
+	return &SlogLogger{logger: l}
+}
+
+// Println 实现Logger接口
+func (s *SlogLogger) Println(msg ...interface{}) {
+	s.logger.Info(strings.TrimSuffix(fmt.Sprintln(msg...), "\n"))
+}
+
+// Log 实现StructuredLogger接口
+func (s *SlogLogger) Log(msg string, keyvals ...interface{}) {
+	s.logger.Info(msg, keyvals...)
+}
+
+// LogAt 实现LeveledLogger接口，将Level映射为slog.Level
+func (s *SlogLogger) LogAt(level Level, msg string, keyvals ...interface{}) {
+	s.logger.Log(context.Background(), slogLevel(level), msg, keyvals...)
+}
+
+// slogLevel 将Level映射为slog.Level
+func slogLevel(level Level) slog.Level {
+	switch level {
+	case LevelDebug:
+		return slog.LevelDebug
+	case LevelWarn:
+		return slog.LevelWarn
+	case LevelError:
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// discardLogger 丢弃所有日志的Logger
+type discardLogger struct{}
+
+// Println 实现Logger接口
+func (discardLogger) Println(msg ...interface{}) {}
+
+// log 输出日志，低于r.level的日志会被丢弃，keyvals为交替出现的key-value对
+// logger没有实现LeveledLogger和StructuredLogger时，所有级别的日志都按照 "msg: v1 key2: v2" 的格式通过Println输出
+func (r *Runner) log(level Level, msg string, keyvals ...interface{}) {
+	if level < r.level || r.logger == (discardLogger{}) {
+		return
+	}
+
+	if l, ok := r.logger.(LeveledLogger); ok {
+		l.LogAt(level, msg, keyvals...)
+		return
+	}
+
+	if l, ok := r.logger.(StructuredLogger); ok {
+		l.Log(msg, keyvals...)
+		return
+	}
+
+	if len(keyvals) == 0 {
+		r.logger.Println(msg)
+		return
+	}
+
+	args := make([]interface{}, 0, len(keyvals))
+	args = append(args, msg+": ")
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		if i > 0 {
+			args = append(args, fmt.Sprintf(" %v: ", keyvals[i]))
+		}
+
+		args = append(args, keyvals[i+1])
+	}
+
+	r.logger.Println(args...)
+}
+
+// taskFields 任务id为k的任务t在日志中的字段，有名称的任务会带上task_name字段
+func (r *Runner) taskFields(k int, t task) []interface{} {
+	if t.name != "" {
+		return []interface{}{"task_id", r.logID(k, t), "task_name", t.name}
+	}
+
+	return []interface{}{"task_id", r.logID(k, t)}
+}
+
+// WithIDFunc 设置任务在日志、回调函数的name、span名称以及GetNamedErrors、GetAllErrorsByID等记录中使用的id，默认为任务index
+// index为任务id，task为通过Add等添加的原始任务，通过AddCtx、AddResult等添加的任务为nil
+// 例如需要从1开始的任务id时可以返回strconv.Itoa(index+1)，fn返回空字符串或者panic时使用任务index
+// fn可能在持有runner内部锁的时候调用，fn中不能再调用runner的方法
+func WithIDFunc(fn func(index int, task func() error) string) Option {
+	return func(r *Runner) {
+		r.idFunc = fn
+	}
+}
+
+// formatID 获取任务id为k的任务t在日志以及错误记录中使用的id
+func (r *Runner) formatID(k int, t task) string {
+	if r.idFunc != nil {
+		id := ""
+		r.safeCall("id func", func() {
+			id = r.idFunc(k, t.raw)
+		})
+
+		if id != "" {
+			return id
+		}
+	}
+
+	return strconv.Itoa(k)
+}
+
+// logID 获取任务id为k的任务t在日志中的task_id字段，没有设置WithIDFunc时为任务index
+func (r *Runner) logID(k int, t task) interface{} {
+	if r.idFunc == nil {
+		return k
+	}
+
+	return r.formatID(k, t)
+}
+
+// logIDAt 获取任务id为k的任务在日志中的task_id字段
+func (r *Runner) logIDAt(k int) interface{} {
+	if r.idFunc == nil {
+		return k
+	}
+
+	r.mu.Lock()
+	t := task{}
+	if k >= 0 && k < len(r.tasks) {
+		t = r.tasks[k]
+	}
+	r.mu.Unlock()
+
+	return r.formatID(k, t)
+}
+
+// taskLoggerKey 任务logger在context中的key
+type taskLoggerKey struct{}
+
+// taskLogger 带有任务id和名称前缀的logger，每一行日志都会以 "[task 1 name] " 开头
+type taskLogger struct {
+	base   Logger
+	prefix string
+}
+
+// Println 实现Logger接口，在日志前面加上任务前缀
+func (l *taskLogger) Println(msg ...interface{}) {
+	l.base.Println(append([]interface{}{l.prefix}, msg...)...)
+}
+
+// WithTaskLogger 为每个任务创建一个带有任务id和名称前缀的logger，通过ctx传递给AddCtx添加的任务
+// 任务中可以通过LoggerFromContext获取，不设置时不会有额外的开销
+func WithTaskLogger() Option {
+	return func(r *Runner) {
+		r.taskLogger = true
+	}
+}
+
+// LoggerFromContext 获取ctx中当前任务的logger，没有设置WithTaskLogger时返回log.Default()
+func LoggerFromContext(ctx context.Context) Logger {
+	if l, ok := ctx.Value(taskLoggerKey{}).(Logger); ok {
+		return l
+	}
+
+	return log.Default()
+}
+
+// withTaskLogger 设置了WithTaskLogger时，将任务k的logger放入ctx中
+func (r *Runner) withTaskLogger(ctx context.Context, k int, t task) context.Context {
+	if !r.taskLogger {
+		return ctx
+	}
+
+	prefix := "[task " + r.formatID(k, t)
+	if t.name != "" {
+		prefix += " " + t.name
+	}
+
+	return context.WithValue(ctx, taskLoggerKey{}, Logger(&taskLogger{base: r.logger, prefix: prefix + "] "}))
+}
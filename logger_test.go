@@ -0,0 +1,152 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// TestSlogLogger test structured logs through slog
+func TestSlogLogger(t *testing.T) {
+	var buf bytes.Buffer
+	l := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	p := New(WithLogger(NewSlogLogger(l)))
+	p.AddNamed("import", func() error { return errors.New("import failed") })
+	_ = p.Start()
+
+	out := buf.String()
+	for _, field := range []string{`"task_id":0`, `"task_name":"import"`, `"error":"import failed"`} {
+		if !strings.Contains(out, field) {
+			t.Fatalf("expected field %s in logs: %s", field, out)
+		}
+	}
+}
+
+// recordLogger 记录所有输出的日志
+type recordLogger struct {
+	lines []string
+}
+
+// Println 实现Logger接口
+func (l *recordLogger) Println(msg ...interface{}) {
+	l.lines = append(l.lines, fmt.Sprint(msg...))
+}
+
+// TestRunnerLogLevel test suppress debug logs by default
+func TestRunnerLogLevel(t *testing.T) {
+	l := &recordLogger{}
+	p := New(WithLogger(l))
+	p.Add(func() error { return nil })
+	_ = p.Start()
+
+	for _, line := range l.lines {
+		if strings.HasPrefix(line, "current run task id") {
+			t.Fatalf("unexpected debug log: %s", line)
+		}
+	}
+
+	l = &recordLogger{}
+	p = New(WithLogger(l), WithLogLevel(LevelDebug))
+	p.Add(func() error { return nil })
+	_ = p.Start()
+
+	if len(l.lines) == 0 || !strings.HasPrefix(l.lines[0], "current run task id") {
+		t.Fatalf("expected debug log, got: %v", l.lines)
+	}
+}
+
+// TestRunnerSilent test discard all logs
+func TestRunnerSilent(t *testing.T) {
+	l := &recordLogger{}
+	p := New(WithLogger(l), WithSilent(), WithLogLevel(LevelDebug))
+	p.Add(func() error { return errors.New("failed") })
+	_ = p.Start()
+
+	if len(l.lines) != 0 {
+		t.Fatalf("expected no logs, got: %v", l.lines)
+	}
+}
+
+// TestRunnerTaskLogger test ctx tasks get a logger prefixed with the task id and name
+func TestRunnerTaskLogger(t *testing.T) {
+	l := &recordLogger{}
+	p := New(WithLogger(l), WithLogLevel(LevelError), WithTaskLogger())
+	p.AddCtx(func(ctx context.Context) error {
+		LoggerFromContext(ctx).Println("hello")
+		return nil
+	})
+	p.AddNamed("named", func() error { return nil })
+	p.AddCtx(func(ctx context.Context) error {
+		LoggerFromContext(ctx).Println("world")
+		return nil
+	})
+
+	if err := p.Start(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(l.lines) != 2 || l.lines[0] != "[task 0] hello" || l.lines[1] != "[task 2] world" {
+		t.Fatalf("unexpected task logs: %q", l.lines)
+	}
+
+	if LoggerFromContext(context.Background()) == nil {
+		t.Fatal("expected default logger without task logger")
+	}
+}
+
+// TestRunnerIDFunc test custom task ids in logs and stored errors
+func TestRunnerIDFunc(t *testing.T) {
+	var buf bytes.Buffer
+	l := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	p := New(WithLogger(NewSlogLogger(l)), WithIDFunc(func(index int, task func() error) string {
+		if task == nil {
+			return "ctx-" + fmt.Sprint(index+1)
+		}
+
+		return fmt.Sprint(index + 1)
+	}))
+	p.Add(func() error { return errors.New("first failed") })
+	p.AddCtx(func(ctx context.Context) error { return errors.New("second failed") })
+	_ = p.Start()
+
+	if !strings.Contains(buf.String(), `"task_id":"1"`) || !strings.Contains(buf.String(), `"task_id":"ctx-2"`) {
+		t.Fatalf("unexpected logs: %s", buf.String())
+	}
+
+	errs := p.GetAllErrorsByID()
+	if errs["1"] == nil || errs["ctx-2"] == nil || len(errs) != 2 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+
+	// 默认使用任务index
+	p = New(WithSilent())
+	p.Add(func() error { return errors.New("failed") })
+	_ = p.Start()
+	if errs := p.GetAllErrorsByID(); errs["0"] == nil {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+}
+
+// TestRunnerIDFuncHooks test hooks and stored errors use the same custom task id
+func TestRunnerIDFuncHooks(t *testing.T) {
+	var names []string
+	p := New(WithSilent(), WithIDFunc(func(index int, task func() error) string {
+		return fmt.Sprint(index + 1)
+	}), WithOnError(func(id int, name string, err error) {
+		names = append(names, name)
+	}))
+	p.Add(func() error { return errors.New("failed") })
+	p.AddNamed("named", func() error { return errors.New("named failed") })
+	_ = p.Start()
+
+	errs := p.GetNamedErrors()
+	if len(names) != 2 || names[0] != "1" || names[1] != "named" || errs["1"] == nil || errs["named"] == nil {
+		t.Fatalf("unexpected names: %v, errors: %v", names, errs)
+	}
+}
@@ -0,0 +1,51 @@
+package runner
+
+import (
+	"errors"
+	"time"
+)
+
+// Collector 任务执行指标的收集接口，可以基于prometheus client_golang等实现
+// runner本身不依赖任何指标库
+type Collector interface {
+	// TaskStarted 任务开始执行
+	TaskStarted(id int)
+	// ObserveTask 任务执行结束，err为nil时表示任务执行成功，d为任务执行的耗时
+	ObserveTask(id int, d time.Duration, err error)
+	// IncPanic 任务执行过程中出现了panic
+	IncPanic()
+}
+
+// WithMetrics 设置任务执行指标的收集器
+func WithMetrics(c Collector) Option {
+	return func(r *Runner) {
+		r.metrics = c
+	}
+}
+
+// observeStart 上报任务开始执行的指标
+func (r *Runner) observeStart(k int) {
+	if r.metrics == nil {
+		return
+	}
+
+	r.safeCall("metrics collector", func() {
+		r.metrics.TaskStarted(k)
+	})
+}
+
+// observeEnd 上报任务执行结束的指标，任务panic时额外上报panic次数
+func (r *Runner) observeEnd(k int, d time.Duration, err error) {
+	if r.metrics == nil {
+		return
+	}
+
+	r.safeCall("metrics collector", func() {
+		var pe *PanicError
+		if errors.As(err, &pe) {
+			r.metrics.IncPanic()
+		}
+
+		r.metrics.ObserveTask(k, d, err)
+	})
+}
@@ -0,0 +1,57 @@
+package runner
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// countCollector 记录任务执行指标的收集器
+type countCollector struct {
+	mu        sync.Mutex
+	started   int
+	succeeded int
+	failed    int
+	panicked  int
+}
+
+func (c *countCollector) TaskStarted(id int) {
+	c.mu.Lock()
+	c.started++
+	c.mu.Unlock()
+}
+
+func (c *countCollector) ObserveTask(id int, d time.Duration, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err != nil {
+		c.failed++
+		return
+	}
+
+	c.succeeded++
+}
+
+func (c *countCollector) IncPanic() {
+	c.mu.Lock()
+	c.panicked++
+	c.mu.Unlock()
+}
+
+// TestRunnerMetrics test collect task metrics
+func TestRunnerMetrics(t *testing.T) {
+	c := &countCollector{}
+	p := New(WithMetrics(c), WithConcurrency(2))
+	p.Add(
+		func() error { return nil },
+		func() error { return errors.New("failed") },
+		func() error { panic("boom") },
+	)
+	_ = p.Start()
+
+	if c.started != 3 || c.succeeded != 1 || c.failed != 2 || c.panicked != 1 {
+		t.Fatalf("unexpected metrics: %+v", c)
+	}
+}
@@ -0,0 +1,56 @@
+package runner
+
+import (
+	"context"
+	"time"
+)
+
+// Middleware 任务中间件，包装任务的执行，可以在next执行前后加入日志、指标、耗时统计等通用逻辑
+// 和http中间件一样，中间件中必须调用next才会执行任务，可以修改next返回的错误
+type Middleware func(next func() error) func() error
+
+// Use 添加任务中间件，先添加的中间件在最外层，每个任务的每次执行都会经过所有的中间件
+// 中间件中的panic和任务中的panic一样会被捕获，可以在任务执行的过程中并发调用，对之后开始执行的任务生效
+func (r *Runner) Use(mw ...Middleware) {
+	r.mu.Lock()
+	r.middlewares = append(r.middlewares, mw...)
+	r.mu.Unlock()
+}
+
+// Timing 统计任务执行耗时的中间件，每次执行结束之后调用report，d为本次执行的耗时，err为本次执行返回的错误
+func Timing(report func(d time.Duration, err error)) Middleware {
+	return func(next func() error) func() error {
+		return func() error {
+			start := time.Now()
+			err := next()
+			report(time.Since(start), err)
+			return err
+		}
+	}
+}
+
+// withMiddlewares 使用已经添加的中间件包装fn，没有中间件时直接返回fn
+func (r *Runner) withMiddlewares(fn func(ctx context.Context) (interface{}, error)) func(ctx context.Context) (interface{}, error) {
+	r.mu.Lock()
+	mws := r.middlewares
+	r.mu.Unlock()
+
+	if len(mws) == 0 {
+		return fn
+	}
+
+	return func(ctx context.Context) (value interface{}, err error) {
+		h := func() error {
+			var e error
+			value, e = fn(ctx)
+			return e
+		}
+
+		for i := len(mws) - 1; i >= 0; i-- {
+			h = mws[i](h)
+		}
+
+		err = h()
+		return value, err
+	}
+}
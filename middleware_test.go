@@ -0,0 +1,53 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestRunnerUse test middlewares wrap each task in order
+func TestRunnerUse(t *testing.T) {
+	var calls []string
+	trace := func(name string) Middleware {
+		return func(next func() error) func() error {
+			return func() error {
+				calls = append(calls, name+">")
+				err := next()
+				calls = append(calls, "<"+name)
+				return err
+			}
+		}
+	}
+
+	errTask := errors.New("task failed")
+	var timings []error
+	p := New(WithSilent())
+	p.Use(trace("a"), trace("b"))
+	p.Use(Timing(func(d time.Duration, err error) {
+		timings = append(timings, err)
+	}))
+	p.Add(func() error {
+		calls = append(calls, "task")
+		return errTask
+	})
+	p.AddResult(func() (interface{}, error) { return 1, nil })
+
+	if err := p.Start(); !errors.Is(err, errTask) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := strings.Join(calls[:5], " "); got != "a> b> task <b <a" {
+		t.Fatalf("unexpected middleware order: %s", got)
+	}
+
+	if fmt.Sprint(timings) != "[task failed <nil>]" {
+		t.Fatalf("unexpected timings: %v", timings)
+	}
+
+	if p.GetResults()[1] != 1 {
+		t.Fatalf("expected result preserved through middlewares, got: %v", p.GetResults())
+	}
+}
@@ -0,0 +1,30 @@
+package runner
+
+// OnComplete 订阅任务的执行结果，可以多次调用注册多个订阅函数，按照注册的顺序同步调用
+// 每个任务执行结束之后调用一次，被跳过的任务以及超时或者强制退出之后才结束的任务不会调用
+// 每次执行所有任务结束之后也会调用一次，此时res.TaskID为-1，res.Err为Start返回的错误，res.Duration为本次执行的总耗时
+// 每个订阅函数中的panic都会被捕获并记录日志，不会影响其他订阅函数，可以在任务执行的过程中并发调用
+func (r *Runner) OnComplete(fn func(res Result)) {
+	r.mu.Lock()
+	r.subscribers = append(r.subscribers, fn)
+	r.mu.Unlock()
+}
+
+// publish 依次调用所有的订阅函数，任务的执行结果在gen不是当前的版本号时丢弃
+// TaskID为-1的汇总结果在freeze之后依然需要发送
+func (r *Runner) publish(gen uint64, res Result) {
+	r.mu.Lock()
+	if res.TaskID >= 0 && gen != r.gen {
+		r.mu.Unlock()
+		return
+	}
+
+	subscribers := r.subscribers
+	r.mu.Unlock()
+
+	for _, fn := range subscribers {
+		r.safeCall("on complete subscriber", func() {
+			fn(res)
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestRunnerOnComplete test multiple subscribers observe every task and the run
+func TestRunnerOnComplete(t *testing.T) {
+	errTask := errors.New("task failed")
+	var first, second []string
+	p := New(WithSilent())
+	p.OnComplete(func(res Result) {
+		first = append(first, fmt.Sprintf("%d:%v", res.TaskID, res.Err))
+		panic("bad subscriber")
+	})
+	p.OnComplete(func(res Result) {
+		second = append(second, fmt.Sprintf("%d:%v", res.TaskID, res.Err))
+	})
+	p.Add(func() error { return nil }, func() error { return errTask })
+	p.AddConditional(func() bool { return false }, func() error { return nil })
+
+	_ = p.Start()
+	want := "[0:<nil> 1:task failed -1:task failed]"
+	if fmt.Sprint(first) != want || fmt.Sprint(second) != want {
+		t.Fatalf("unexpected subscriber calls: %v %v", first, second)
+	}
+}
+
+// TestRunnerOnCompleteAfterTimeout test results of tasks finished after a timeout are not published
+func TestRunnerOnCompleteAfterTimeout(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		events []string
+	)
+	record := func(s string) {
+		mu.Lock()
+		events = append(events, s)
+		mu.Unlock()
+	}
+
+	finished := make(chan struct{})
+	p := New(WithSilent(), WithTimeout(20*time.Millisecond), WithAfterTask(func(id int, name string, err error, d time.Duration) {
+		record(fmt.Sprintf("after:%d", id))
+	}))
+	p.OnComplete(func(res Result) {
+		record(fmt.Sprintf("complete:%d", res.TaskID))
+	})
+	p.Add(func() error {
+		defer close(finished)
+		time.Sleep(60 * time.Millisecond)
+		return nil
+	})
+
+	if err := p.Start(); !errors.Is(err, ErrRunTimeout) {
+		t.Fatalf("expected ErrRunTimeout, got: %v", err)
+	}
+
+	<-finished
+	time.Sleep(10 * time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if fmt.Sprint(events) != "[complete:-1]" {
+		t.Fatalf("unexpected events: %v", events)
+	}
+}
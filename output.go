@@ -0,0 +1,93 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sync"
+)
+
+// outputKey 任务输出的writer在context中的key
+type outputKey struct{}
+
+// outputBuffer 并发安全的任务输出缓冲区，任务可能在多个goroutine中同时写入
+type outputBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+// Write 实现io.Writer接口
+func (b *outputBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Write(p)
+}
+
+// Bytes 获取已经写入的所有输出
+func (b *outputBuffer) Bytes() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return bytes.Clone(b.buf.Bytes())
+}
+
+// WithCaptureOutput 为每个任务创建一个收集输出的writer，通过ctx传递给AddCtx添加的任务
+// 任务中可以通过OutputFromContext获取，例如作为exec.Cmd的Stdout和Stderr，任务结束之后可以通过GetOutputs获取
+// runner不会劫持os.Stdout，任务直接输出到os.Stdout的内容不会被收集
+func WithCaptureOutput() Option {
+	return func(r *Runner) {
+		r.captureOutput = true
+	}
+}
+
+// OutputFromContext 获取ctx中当前任务收集输出的writer，没有设置WithCaptureOutput时返回io.Discard
+func OutputFromContext(ctx context.Context) io.Writer {
+	if w, ok := ctx.Value(outputKey{}).(io.Writer); ok {
+		return w
+	}
+
+	return io.Discard
+}
+
+// GetOutputs 获取已经完成任务的输出，key为任务index，没有任何输出的任务不会被记录
+func (r *Runner) GetOutputs() map[int][]byte {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	outputs := make(map[int][]byte, len(r.outputs))
+	for k, out := range r.outputs {
+		outputs[k] = out
+	}
+
+	return outputs
+}
+
+// withOutput 设置了WithCaptureOutput时，将收集任务输出的writer放入ctx中
+func (r *Runner) withOutput(ctx context.Context) (context.Context, *outputBuffer) {
+	if !r.captureOutput {
+		return ctx, nil
+	}
+
+	out := &outputBuffer{}
+	return context.WithValue(ctx, outputKey{}, io.Writer(out)), out
+}
+
+// setOutput 记录任务id为k的任务的输出，gen不是当前的版本号时丢弃
+func (r *Runner) setOutput(gen uint64, k int, out *outputBuffer) {
+	if out == nil {
+		return
+	}
+
+	b := out.Bytes()
+	if len(b) == 0 {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if gen == r.gen {
+		r.outputs[k] = b
+	}
+}
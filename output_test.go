@@ -0,0 +1,39 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+// TestRunnerCaptureOutput test outputs collected per task
+func TestRunnerCaptureOutput(t *testing.T) {
+	p := New(WithSilent(), WithCaptureOutput())
+	p.AddCtx(func(ctx context.Context) error {
+		fmt.Fprint(OutputFromContext(ctx), "hello")
+		return nil
+	}, func(ctx context.Context) error {
+		return nil
+	}, func(ctx context.Context) error {
+		fmt.Fprint(OutputFromContext(ctx), "failed output")
+		return fmt.Errorf("failed")
+	})
+
+	_ = p.Start()
+	outputs := p.GetOutputs()
+	if len(outputs) != 2 || string(outputs[0]) != "hello" || string(outputs[2]) != "failed output" {
+		t.Fatalf("unexpected outputs: %q", outputs)
+	}
+
+	// 没有设置WithCaptureOutput时输出会被丢弃
+	p = New(WithSilent())
+	p.AddCtx(func(ctx context.Context) error {
+		fmt.Fprint(OutputFromContext(ctx), "dropped")
+		return nil
+	})
+
+	_ = p.Start()
+	if len(p.GetOutputs()) != 0 {
+		t.Fatalf("unexpected outputs: %q", p.GetOutputs())
+	}
+}
@@ -0,0 +1,128 @@
+package runner
+
+import (
+	"context"
+	"time"
+)
+
+// WithPauseStopsTimer 设置暂停期间停止WithTimeout的超时计时，恢复执行之后继续计时
+// 只对WithTimeout生效，WithDeadline设置的是绝对时间点，不受暂停的影响
+// 设置之后任务中的ctx超时后返回的是context.Canceled，和WithTimeout一样可以通过context.Cause获取到ErrRunTimeout
+func WithPauseStopsTimer() Option {
+	return func(r *Runner) {
+		r.pauseStopsTimer = true
+	}
+}
+
+// Pause 暂停执行任务，正在执行的任务会继续执行完毕，下一个任务开始之前阻塞直到调用Resume
+// 暂停期间Start不会返回，接收到中断信号或者ctx结束时依然会停止执行
+// 在Start之前调用时，下一次执行会在第一个任务之前暂停
+func (r *Runner) Pause() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.paused {
+		return
+	}
+
+	r.paused = true
+	r.resumeCh = make(chan struct{})
+	close(r.pauseChLocked())
+}
+
+// Resume 恢复执行被Pause暂停的任务，没有暂停时调用不会有任何影响
+func (r *Runner) Resume() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.paused {
+		return
+	}
+
+	r.paused = false
+	r.pauseCh = make(chan struct{})
+	close(r.resumeCh)
+}
+
+// IsPaused 是否已经暂停，可以在其他goroutine中并发调用
+func (r *Runner) IsPaused() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.paused
+}
+
+// pauseChLocked 获取调用Pause时关闭的通道，调用方需要持有r.mu
+func (r *Runner) pauseChLocked() chan struct{} {
+	if r.pauseCh == nil {
+		r.pauseCh = make(chan struct{})
+	}
+
+	return r.pauseCh
+}
+
+// pauseState 获取当前是否暂停，以及暂停和恢复时关闭的通道
+func (r *Runner) pauseState() (paused bool, pauseCh, resumeCh chan struct{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.paused, r.pauseChLocked(), r.resumeCh
+}
+
+// waitResume 暂停时阻塞直到调用Resume，等待过程中ctx结束或者接收到中断信号时返回false
+func (r *Runner) waitResume(ctx context.Context) bool {
+	paused, _, resumeCh := r.pauseState()
+	if !paused {
+		return true
+	}
+
+	r.log(LevelInfo, "runner paused")
+	select {
+	case <-resumeCh:
+		r.log(LevelInfo, "runner resumed")
+		return true
+	case <-ctx.Done():
+		return false
+	case <-r.interruptedCh():
+		return false
+	}
+}
+
+// withPausableTimeout 派生出一个暂停期间停止计时的context，超时之后以ErrRunTimeout为cause取消
+func (r *Runner) withPausableTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	go r.pausableTimer(ctx, cancel, d)
+
+	return ctx, func() {
+		cancel(context.Canceled)
+	}
+}
+
+// pausableTimer 剩余时间remaining用完之后调用cancel，暂停期间不计时，ctx结束后退出
+func (r *Runner) pausableTimer(ctx context.Context, cancel context.CancelCauseFunc, remaining time.Duration) {
+	for {
+		paused, pauseCh, resumeCh := r.pauseState()
+		if paused {
+			select {
+			case <-resumeCh:
+				continue
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		start := r.now()
+		timeCh, stop := r.after(remaining)
+		select {
+		case <-timeCh:
+			cancel(ErrRunTimeout)
+			return
+		case <-pauseCh:
+			stop()
+			remaining -= r.since(start)
+		case <-ctx.Done():
+			stop()
+			return
+		}
+	}
+}
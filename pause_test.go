@@ -0,0 +1,84 @@
+package runner
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestRunnerPause test pause blocks at the next task boundary until resume
+func TestRunnerPause(t *testing.T) {
+	p := New(WithSilent())
+	started := make(chan struct{})
+	executed := make(chan struct{})
+	p.Add(func() error {
+		p.Pause()
+		close(started)
+		return nil
+	}, func() error {
+		close(executed)
+		return nil
+	})
+
+	done := p.StartChan()
+	<-started
+
+	select {
+	case <-executed:
+		t.Fatal("expected second task blocked while paused")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if !p.IsPaused() {
+		t.Fatal("expected runner paused")
+	}
+
+	p.Resume()
+	if err := <-done; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if p.IsPaused() {
+		t.Fatal("expected runner resumed")
+	}
+}
+
+// TestRunnerPauseStopsTimer test the timeout does not count while paused
+func TestRunnerPauseStopsTimer(t *testing.T) {
+	for _, stopTimer := range []bool{true, false} {
+		opts := []Option{WithSilent(), WithTimeout(60 * time.Millisecond)}
+		if stopTimer {
+			opts = append(opts, WithPauseStopsTimer())
+		}
+
+		p := New(opts...)
+		p.Add(func() error {
+			p.Pause()
+			go func() {
+				time.Sleep(120 * time.Millisecond)
+				p.Resume()
+			}()
+
+			return nil
+		}, func() error { return nil })
+
+		err := p.Start()
+		if stopTimer && err != nil {
+			t.Fatalf("expected no timeout while paused, got: %v", err)
+		}
+
+		if !stopTimer && !errors.Is(err, ErrTimeout) {
+			t.Fatalf("expected timeout, got: %v", err)
+		}
+	}
+
+	p := New(WithSilent(), WithTimeout(20*time.Millisecond), WithPauseStopsTimer())
+	p.Add(func() error {
+		time.Sleep(100 * time.Millisecond)
+		return nil
+	})
+
+	if err := p.Start(); !errors.Is(err, ErrTimeout) {
+		t.Fatalf("expected timeout without pause, got: %v", err)
+	}
+}
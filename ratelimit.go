@@ -0,0 +1,81 @@
+package runner
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// tokenBucket 令牌桶限流器，每秒产生rate个令牌，最多存放burst个令牌
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64   // 每秒产生的令牌数量
+	burst  int       // 令牌桶的容量
+	tokens float64   // 当前可用的令牌数量
+	last   time.Time // 上一次计算令牌数量的时间，为零值时表示还没有预定过令牌
+}
+
+// newTokenBucket 创建一个令牌桶，初始时令牌桶是满的
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &tokenBucket{
+		rate:   rate,
+		burst:  burst,
+		tokens: float64(burst),
+	}
+}
+
+// reserve 在now时刻预定一个令牌，返回获取到该令牌之前需要等待的时间
+// now由runner的时钟提供，WithClock设置的时钟和等待令牌使用的时钟保持一致
+func (b *tokenBucket) reserve(now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.last.IsZero() {
+		b.last = now
+	}
+
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > float64(b.burst) {
+		b.tokens = float64(b.burst)
+	}
+
+	b.last = now
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// WithRateLimit 设置任务执行的速率限制，每秒最多执行rate个任务，允许最多burst个任务的突发
+// 每个任务执行之前都需要先获取一个令牌，等待令牌的过程可以被ctx取消或者中断信号打断
+// 设置了WithConcurrency时，限流作用于所有worker，令牌的产生以及等待都使用WithClock设置的时钟
+func WithRateLimit(rate float64, burst int) Option {
+	return func(r *Runner) {
+		if rate <= 0 {
+			r.limiter = nil
+			return
+		}
+
+		r.limiter = newTokenBucket(rate, burst)
+	}
+}
+
+// waitRateLimit 等待获取一个令牌，ctx结束或者接收到中断信号时返回false
+func (r *Runner) waitRateLimit(ctx context.Context) bool {
+	if r.limiter == nil {
+		return true
+	}
+
+	d := r.limiter.reserve(r.now())
+	if d <= 0 {
+		return true
+	}
+
+	return r.sleepCtx(ctx, d)
+}
@@ -0,0 +1,24 @@
+package runner
+
+import (
+	"testing"
+	"time"
+)
+
+// TestRunnerRateLimit test limit the rate of tasks
+func TestRunnerRateLimit(t *testing.T) {
+	p := New(WithRateLimit(50, 2), WithConcurrency(4))
+	for i := 0; i < 7; i++ {
+		p.Add(func() error { return nil })
+	}
+
+	start := time.Now()
+	if err := p.Start(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// 前2个任务使用突发的令牌，后5个任务需要等待 5 / 50 = 100ms
+	if d := time.Since(start); d < 90*time.Millisecond {
+		t.Fatalf("expected rate limited, got: %v", d)
+	}
+}
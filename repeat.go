@@ -0,0 +1,44 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// WithRepeat 设置每次执行完所有的任务之后，等待every再次执行所有的任务，每次执行之前都会重置上一次执行的状态
+// maxRuns为最大的执行次数，0表示一直重复执行，直到接收到中断信号、调用Stop或者ctx结束
+// 间隔期间接收到中断信号时Start立即返回ErrInterrupt，某一次执行返回的任务错误或者超时不会停止重复执行
+// Start返回的是最后一次执行的错误，每一次执行中任务的结果可以通过WithAfterTask等回调获取
+func WithRepeat(every time.Duration, maxRuns int) Option {
+	return func(r *Runner) {
+		r.repeatEvery = every
+		r.maxRuns = maxRuns
+	}
+}
+
+// RunCount 获取已经执行所有任务的次数，每一次Start以及WithRepeat中的每一次重复执行都会累加
+func (r *Runner) RunCount() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.runCount
+}
+
+// shouldRepeat 本次Start中的第n次执行结束之后，累加执行次数并判断是否需要再次执行
+func (r *Runner) shouldRepeat(ctx context.Context, n int, err error) bool {
+	r.mu.Lock()
+	r.runCount++
+	r.mu.Unlock()
+
+	if r.repeatEvery <= 0 || errors.Is(err, ErrInterrupt) || ctx.Err() != nil || r.deadlineExceeded() {
+		return false
+	}
+
+	return r.maxRuns <= 0 || n < r.maxRuns
+}
+
+// waitRepeat 等待r.repeatEvery之后再次执行，等待过程中接收到中断信号或者ctx结束时返回对应的错误
+func (r *Runner) waitRepeat(ctx context.Context) error {
+	return r.waitDelay(ctx, r.repeatEvery)
+}
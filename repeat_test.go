@@ -0,0 +1,59 @@
+package runner
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestRunnerRepeat test run all tasks maxRuns times
+func TestRunnerRepeat(t *testing.T) {
+	executed := 0
+	p := New(WithSilent(), WithRepeat(10*time.Millisecond, 3))
+	p.Add(func() error {
+		executed++
+		return errors.New("failed")
+	})
+
+	start := time.Now()
+	if err := p.Start(); err == nil || executed != 3 || p.RunCount() != 3 {
+		t.Fatalf("unexpected repeat result: %v executed: %d runs: %d", err, executed, p.RunCount())
+	}
+
+	if d := time.Since(start); d < 20*time.Millisecond {
+		t.Fatalf("expected wait between runs, got: %v", d)
+	}
+
+	// 每次执行之前重置上一次执行的状态
+	if errs := p.GetAllErrors(); len(errs) != 1 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+}
+
+// TestRunnerRepeatInterrupt test stop between runs promptly
+func TestRunnerRepeatInterrupt(t *testing.T) {
+	executed := 0
+	p := New(WithSilent(), WithNoSignals(), WithRepeat(time.Hour, 0))
+	p.Add(func() error {
+		executed++
+		return nil
+	})
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		p.Stop()
+	}()
+
+	start := time.Now()
+	if err := p.Start(); !errors.Is(err, ErrInterrupt) || time.Since(start) > time.Second {
+		t.Fatalf("expected interrupt between runs, got: %v", err)
+	}
+
+	if executed != 1 || p.RunCount() != 1 {
+		t.Fatalf("unexpected runs: executed %d count %d", executed, p.RunCount())
+	}
+
+	if !p.Interrupted() || p.TimedOut() || !p.Stats().Interrupted {
+		t.Fatalf("expected interrupted outcome, got: %v %v", p.Interrupted(), p.Stats())
+	}
+}
@@ -0,0 +1,212 @@
+package runner
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// RunReport 一次执行的汇总结果
+type RunReport struct {
+	Total       int                   // 任务队列中的任务数量
+	Succeeded   int                   // 执行成功的任务数量
+	Failed      map[int]error         // 执行失败的任务id以及对应的错误
+	Skipped     []int                 // 被跳过的任务id，从小到大排序
+	Names       map[int]string        // 有名称的任务id对应的名称
+	Durations   map[int]time.Duration // 已经完成任务的执行耗时
+	Duration    time.Duration         // 本次执行的总耗时
+	TimedOut    bool                  // 是否因为超时结束
+	Interrupted bool                  // 是否因为接收到中断信号或者调用Stop结束
+}
+
+// WithOnFinish 设置每次执行结束之后调用的汇总回调，无论执行成功、超时还是被中断都只会调用一次
+// 适用于发送一条汇总的告警消息或者审计记录，在WithFinalizer之后、Start返回之前调用，fn中的panic会被捕获并记录日志
+func WithOnFinish(fn func(report RunReport)) Option {
+	return func(r *Runner) {
+		r.onFinish = fn
+	}
+}
+
+// Run 执行所有的任务，返回本次执行的汇总结果以及与Start相同的错误
+func (r *Runner) Run() (RunReport, error) {
+	err := r.Start()
+	if errors.Is(err, ErrRunning) {
+		return RunReport{}, err
+	}
+
+	return r.report(err), err
+}
+
+// report 根据本次执行的结果生成汇总结果，err为Start返回的错误
+func (r *Runner) report(err error) RunReport {
+	skipped := r.GetSkipped()
+	duration := r.TotalDuration()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rep := RunReport{
+		Total:       len(r.tasks),
+		Failed:      make(map[int]error, len(r.allErrors)),
+		Skipped:     skipped,
+		Names:       make(map[int]string),
+		Durations:   make(map[int]time.Duration, len(r.results)),
+		Duration:    duration,
+		TimedOut:    errors.Is(err, ErrRunTimeout),
+		Interrupted: errors.Is(err, ErrInterrupt),
+	}
+
+	for k, t := range r.tasks {
+		if t.name != "" {
+			rep.Names[k] = t.name
+		}
+	}
+
+	for k, err := range r.allErrors {
+		rep.Failed[k] = err
+	}
+
+	for k, res := range r.results {
+		rep.Durations[k] = res.Duration
+		if res.Err == nil {
+			rep.Succeeded++
+		}
+	}
+
+	return rep
+}
+
+// taskReportJSON 单个任务在json中的执行结果
+type taskReportJSON struct {
+	ID         int     `json:"id"`
+	Name       string  `json:"name,omitempty"`
+	Status     string  `json:"status"`
+	Failed     bool    `json:"failed"`
+	Error      string  `json:"error,omitempty"`
+	DurationMs float64 `json:"duration_ms"`
+}
+
+// runReportJSON RunReport在json中的格式
+type runReportJSON struct {
+	Status      string           `json:"status"`
+	Total       int              `json:"total"`
+	Succeeded   int              `json:"succeeded"`
+	Failed      int              `json:"failed"`
+	Skipped     int              `json:"skipped"`
+	DurationMs  float64          `json:"duration_ms"`
+	TimedOut    bool             `json:"timed_out"`
+	Interrupted bool             `json:"interrupted"`
+	Tasks       []taskReportJSON `json:"tasks"`
+}
+
+// MarshalJSON 将RunReport输出为json，错误输出为Error()返回的字符串，耗时的单位为毫秒
+// 每个任务的status为succeeded、failed、skipped或者not_run，整体的status为succeeded、failed、timeout或者interrupted
+func (rep RunReport) MarshalJSON() ([]byte, error) {
+	out := runReportJSON{
+		Status:      "succeeded",
+		Total:       rep.Total,
+		Succeeded:   rep.Succeeded,
+		Failed:      len(rep.Failed),
+		Skipped:     len(rep.Skipped),
+		DurationMs:  milliseconds(rep.Duration),
+		TimedOut:    rep.TimedOut,
+		Interrupted: rep.Interrupted,
+		Tasks:       make([]taskReportJSON, 0, rep.Total),
+	}
+
+	switch {
+	case rep.TimedOut:
+		out.Status = "timeout"
+	case rep.Interrupted:
+		out.Status = "interrupted"
+	case len(rep.Failed) > 0:
+		out.Status = "failed"
+	}
+
+	skipped := make(map[int]bool, len(rep.Skipped))
+	for _, k := range rep.Skipped {
+		skipped[k] = true
+	}
+
+	for k := 0; k < rep.Total; k++ {
+		t := taskReportJSON{ID: k, Name: rep.Names[k], Status: "not_run"}
+		d, done := rep.Durations[k]
+		if err, ok := rep.Failed[k]; ok {
+			t.Status = "failed"
+			t.Failed = true
+			t.Error = err.Error()
+		} else if skipped[k] {
+			t.Status = "skipped"
+		} else if done {
+			t.Status = "succeeded"
+		}
+
+		t.DurationMs = milliseconds(d)
+		out.Tasks = append(out.Tasks, t)
+	}
+
+	return json.Marshal(out)
+}
+
+// milliseconds 将d转换为毫秒
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// Stats 一次执行的统计信息，和RunReport不同的是不包含每个任务的详细结果，适用于监控面板以及汇总日志
+type Stats struct {
+	Total         int           // 任务队列中的任务数量
+	Succeeded     int           // 执行成功的任务数量
+	Failed        int           // 执行失败的任务数量，包括panic的任务
+	Panicked      int           // 出现panic的任务数量
+	Skipped       int           // 被跳过的任务数量
+	Retried       int           // 执行次数超过1次的任务数量
+	TotalDuration time.Duration // 本次执行的总耗时
+	AvgDuration   time.Duration // 已经完成任务的平均耗时
+	MaxDuration   time.Duration // 已经完成任务的最大耗时
+	TimedOut      bool          // 是否因为超时结束
+	Interrupted   bool          // 是否因为接收到中断信号或者调用Stop结束
+}
+
+// Stats 获取最后一次执行的统计信息，执行的过程中调用时返回已经完成任务的统计信息
+func (r *Runner) Stats() Stats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	st := Stats{
+		Total:         len(r.tasks),
+		Skipped:       len(r.skipped),
+		TotalDuration: r.elapsedLocked(),
+		TimedOut:      r.timedOut,
+		Interrupted:   r.wasInterrupted,
+	}
+
+	var total time.Duration
+	for _, res := range r.results {
+		total += res.Duration
+		if res.Duration > st.MaxDuration {
+			st.MaxDuration = res.Duration
+		}
+
+		if res.Attempts > 1 {
+			st.Retried++
+		}
+
+		if res.Err == nil {
+			st.Succeeded++
+			continue
+		}
+
+		st.Failed++
+		var pe *PanicError
+		if errors.As(res.Err, &pe) {
+			st.Panicked++
+		}
+	}
+
+	if len(r.results) > 0 {
+		st.AvgDuration = total / time.Duration(len(r.results))
+	}
+
+	return st
+}
@@ -0,0 +1,147 @@
+package runner
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestRunnerRun test the run report summarizes a run
+func TestRunnerRun(t *testing.T) {
+	errTask := errors.New("task failed")
+	p := New(WithSilent())
+	p.Add(func() error { return nil }, func() error { return errTask })
+	p.AddConditional(func() bool { return false }, func() error { return nil })
+	p.Add(func() error { return nil })
+
+	rep, err := p.Run()
+	if !errors.Is(err, errTask) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if rep.Total != 4 || rep.Succeeded != 2 || len(rep.Failed) != 1 || rep.Failed[1] != errTask {
+		t.Fatalf("unexpected report: %+v", rep)
+	}
+
+	if len(rep.Skipped) != 1 || rep.Skipped[0] != 2 || len(rep.Durations) != 3 {
+		t.Fatalf("unexpected report: %+v", rep)
+	}
+
+	if rep.TimedOut || rep.Interrupted {
+		t.Fatalf("unexpected report status: %+v", rep)
+	}
+
+	p = New(WithSilent(), WithTimeout(10*time.Millisecond))
+	p.Add(func() error {
+		time.Sleep(100 * time.Millisecond)
+		return nil
+	})
+
+	if rep, err = p.Run(); !errors.Is(err, ErrTimeout) || !rep.TimedOut {
+		t.Fatalf("expected timed out report, got: %+v %v", rep, err)
+	}
+}
+
+// TestRunReportMarshalJSON test errors are rendered as strings in json
+func TestRunReportMarshalJSON(t *testing.T) {
+	p := New(WithSilent())
+	p.AddNamed("ok", func() error { return nil })
+	p.Add(func() error { return errors.New("boom") })
+	p.AddConditional(func() bool { return false }, func() error { return nil })
+
+	rep, _ := p.Run()
+	b, err := json.Marshal(rep)
+	if err != nil {
+		t.Fatalf("unexpected marshal error: %v", err)
+	}
+
+	var out struct {
+		Status string `json:"status"`
+		Tasks  []struct {
+			ID     int    `json:"id"`
+			Name   string `json:"name"`
+			Status string `json:"status"`
+			Failed bool   `json:"failed"`
+			Error  string `json:"error"`
+		} `json:"tasks"`
+	}
+
+	if err := json.Unmarshal(b, &out); err != nil {
+		t.Fatalf("unexpected unmarshal error: %v", err)
+	}
+
+	if out.Status != "failed" || len(out.Tasks) != 3 {
+		t.Fatalf("unexpected json: %s", b)
+	}
+
+	if out.Tasks[0].Name != "ok" || out.Tasks[0].Status != "succeeded" ||
+		!out.Tasks[1].Failed || out.Tasks[1].Error != "boom" || out.Tasks[2].Status != "skipped" {
+		t.Fatalf("unexpected json: %s", b)
+	}
+}
+
+// TestRunnerOnFinish test the finish callback receives the report once per run
+func TestRunnerOnFinish(t *testing.T) {
+	var order []string
+	reports := make([]RunReport, 0)
+	p := New(WithSilent(), WithTimeout(20*time.Millisecond),
+		WithFinalizer(func(err error) { order = append(order, "finalizer") }),
+		WithOnFinish(func(rep RunReport) {
+			order = append(order, "finish")
+			reports = append(reports, rep)
+			panic("finish panic")
+		}),
+	)
+	p.Add(func() error { return errors.New("failed") })
+	p.Add(func() error {
+		time.Sleep(100 * time.Millisecond)
+		return nil
+	})
+
+	if err := p.Start(); !errors.Is(err, ErrTimeout) {
+		t.Fatalf("expected ErrTimeout, got: %v", err)
+	}
+
+	if len(order) != 2 || order[0] != "finalizer" || order[1] != "finish" {
+		t.Fatalf("unexpected callback order: %v", order)
+	}
+
+	if rep := reports[0]; !rep.TimedOut || rep.Total != 2 || len(rep.Failed) != 1 {
+		t.Fatalf("unexpected report: %+v", rep)
+	}
+}
+
+// TestRunnerStats test the compact stats of a run
+func TestRunnerStats(t *testing.T) {
+	attempts := 0
+	p := New(WithSilent(), WithRetry(2, time.Millisecond))
+	p.Add(func() error {
+		time.Sleep(10 * time.Millisecond)
+		return nil
+	})
+	p.Add(func() error {
+		if attempts++; attempts == 1 {
+			return errors.New("retry")
+		}
+
+		return nil
+	})
+	p.Add(func() error { panic("boom") })
+	p.AddConditional(func() bool { return false }, func() error { return nil })
+	p.Add(func() error { return errors.New("failed") })
+	_ = p.Start()
+
+	st := p.Stats()
+	if st.Total != 5 || st.Succeeded != 2 || st.Failed != 2 || st.Panicked != 1 || st.Skipped != 1 {
+		t.Fatalf("unexpected stats: %+v", st)
+	}
+
+	if st.Retried < 1 || st.MaxDuration < 10*time.Millisecond || st.AvgDuration <= 0 || st.AvgDuration > st.MaxDuration {
+		t.Fatalf("unexpected stats: %+v", st)
+	}
+
+	if st.TotalDuration < st.MaxDuration || st.TimedOut || st.Interrupted {
+		t.Fatalf("unexpected stats: %+v", st)
+	}
+}
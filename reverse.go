@@ -0,0 +1,68 @@
+package runner
+
+import "errors"
+
+// ErrReverseDependency reverse order does not support task dependencies
+var ErrReverseDependency = errors.New("reverse order does not support task dependencies")
+
+// WithReverseOrder 设置按照添加顺序的倒序执行r.tasks中的任务，最后添加的任务最先执行，类似于defer的清理逻辑
+// 任务id依然是任务添加时的index，GetAllErrors、GetLastTaskId等返回的都是添加时的index
+// 和WithStopOnError一起使用时，停止在倒序执行中第一个出错的任务，即出错的任务中index最大的任务
+// AddIfPrevSucceeded等依赖的前一个任务为倒序执行中的前一个任务，即index加1的任务，StartFrom的index依然为任务id，
+// 从该任务开始倒序执行到第一个任务，执行过程中添加的任务不会被执行，存在依赖关系时Start返回ErrReverseDependency
+// 只对r.tasks中的任务生效，StartStream依然按照从通道中接收的顺序执行
+func WithReverseOrder() Option {
+	return func(r *Runner) {
+		r.reverse = true
+	}
+}
+
+// prepareReverse 设置了WithReverseOrder时，记录本次倒序执行的任务数量
+func (r *Runner) prepareReverse() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.reverse {
+		return nil
+	}
+
+	for _, t := range r.tasks {
+		if len(t.deps) > 0 {
+			return ErrReverseDependency
+		}
+	}
+
+	r.reverseN = len(r.tasks)
+	return nil
+}
+
+// taskID 获取本次执行中第pos个执行的任务id
+func (r *Runner) taskID(pos int) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.taskIDLocked(pos)
+}
+
+// taskIDLocked 获取本次执行中第pos个执行的任务id，调用方需要持有r.mu
+func (r *Runner) taskIDLocked(pos int) int {
+	if r.reverseN > 0 {
+		return r.reverseN - 1 - pos
+	}
+
+	return pos
+}
+
+// posLocked 获取任务id为k的任务在本次执行中的执行顺序，调用方需要持有r.mu
+func (r *Runner) posLocked(k int) int {
+	return r.taskIDLocked(k)
+}
+
+// runLenLocked 获取本次执行的任务数量，调用方需要持有r.mu
+func (r *Runner) runLenLocked() int {
+	if r.reverseN > 0 {
+		return r.reverseN
+	}
+
+	return len(r.tasks)
+}
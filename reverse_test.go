@@ -0,0 +1,110 @@
+package runner
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// TestRunnerReverseOrder test tasks executed in reverse order
+func TestRunnerReverseOrder(t *testing.T) {
+	for _, concurrency := range []int{1, 2} {
+		p := New(WithSilent(), WithReverseOrder(), WithConcurrency(concurrency))
+		order := make([]int, 0)
+		for i := 0; i < 4; i++ {
+			i := i
+			p.Add(func() error {
+				p.mu.Lock()
+				order = append(order, i)
+				p.mu.Unlock()
+				if i == 1 {
+					return errors.New("task 1 failed")
+				}
+
+				return nil
+			})
+		}
+
+		_ = p.Start()
+		if len(order) != 4 {
+			t.Fatalf("unexpected order: %v", order)
+		}
+
+		if concurrency == 1 && !reflect.DeepEqual(order, []int{3, 2, 1, 0}) {
+			t.Fatalf("unexpected order: %v", order)
+		}
+
+		if _, ok := p.GetAllErrors()[1]; !ok || len(p.GetAllErrors()) != 1 {
+			t.Fatalf("expected error of task 1, got: %v", p.GetAllErrors())
+		}
+	}
+}
+
+// TestRunnerReverseStopOnError test reverse order stops at the error with the highest index
+func TestRunnerReverseStopOnError(t *testing.T) {
+	p := New(WithSilent(), WithReverseOrder(), WithStopOnError())
+	order := make([]int, 0)
+	for i := 0; i < 5; i++ {
+		i := i
+		p.Add(func() error {
+			order = append(order, i)
+			if i == 1 || i == 3 {
+				return errors.New("failed")
+			}
+
+			return nil
+		})
+	}
+
+	if err := p.Start(); err == nil {
+		t.Fatal("expected error")
+	}
+
+	if !reflect.DeepEqual(order, []int{4, 3}) || p.GetLastTaskId() != 3 {
+		t.Fatalf("unexpected order: %v %d", order, p.GetLastTaskId())
+	}
+}
+
+// TestRunnerReverseStartFrom test StartFrom and AddIfPrevSucceeded in reverse order
+func TestRunnerReverseStartFrom(t *testing.T) {
+	p := New(WithSilent(), WithReverseOrder())
+	order := make([]int, 0)
+	for i := 0; i < 4; i++ {
+		i := i
+		fn := func() error {
+			order = append(order, i)
+			if i == 2 {
+				return errors.New("failed")
+			}
+
+			return nil
+		}
+
+		if i == 1 {
+			p.AddIfPrevSucceeded(fn)
+			continue
+		}
+
+		p.Add(fn)
+	}
+
+	if err := p.StartFrom(2); err == nil {
+		t.Fatal("expected error")
+	}
+
+	// 任务1依赖倒序执行中的前一个任务，即任务2
+	if !reflect.DeepEqual(order, []int{2, 0}) {
+		t.Fatalf("unexpected order: %v", order)
+	}
+}
+
+// TestRunnerReverseDependency test reverse order with task dependencies
+func TestRunnerReverseDependency(t *testing.T) {
+	p := New(WithSilent(), WithReverseOrder())
+	p.AddDependent("a", nil, func() error { return nil })
+	p.AddDependent("b", []string{"a"}, func() error { return nil })
+
+	if err := p.Start(); !errors.Is(err, ErrReverseDependency) {
+		t.Fatalf("expected ErrReverseDependency, got: %v", err)
+	}
+}
@@ -1,4 +1,5 @@
-/** Package runner is used to execute program task operations in sequence,
+/*
+* Package runner is used to execute program task operations in sequence,
 which can be used as cron job or timing task.
 The runner package can be used to show how to use the channel to monitor
 the execution time of the program.
@@ -20,11 +21,16 @@ More efficient monitoring, etc.
 package runner
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log"
 	"os"
 	"os/signal"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 )
@@ -33,10 +39,55 @@ var (
 	// ErrTimeout task exec timeout
 	ErrTimeout = errors.New("task exec timeout")
 
+	// ErrRunTimeout all tasks are not finished before the run timeout, errors.Is(err, ErrTimeout) is true
+	ErrRunTimeout = fmt.Errorf("run timeout: %w", ErrTimeout)
+
+	// ErrTaskTimeout single task exec timeout, errors.Is(err, ErrTimeout) is true
+	ErrTaskTimeout = fmt.Errorf("single task timeout: %w", ErrTimeout)
+
 	// ErrInterrupt recv interrupt signal
 	ErrInterrupt = errors.New("received interrupt signal")
+
+	// ErrTooManyErrors the number of task errors exceed the threshold
+	ErrTooManyErrors = errors.New("too many task errors")
+
+	// ErrRunning runner is running
+	ErrRunning = errors.New("runner is running")
+
+	// ErrStopped runner is stopped by Stop, errors.Is(err, ErrInterrupt) is true
+	ErrStopped = fmt.Errorf("stopped by Stop: %w", ErrInterrupt)
+
+	// ErrNoSuchTask task index is out of range
+	ErrNoSuchTask = errors.New("no such task")
 )
 
+// InterruptError 接收到中断信号时返回的错误，可以通过errors.As获取具体的信号
+// errors.Is(err, ErrInterrupt)为true
+type InterruptError struct {
+	Signal os.Signal // 接收到的中断信号
+}
+
+// Error 实现error接口
+func (e *InterruptError) Error() string {
+	if e.Signal == nil {
+		return ErrInterrupt.Error()
+	}
+
+	return ErrInterrupt.Error() + ": " + e.Signal.String()
+}
+
+// Unwrap 返回ErrInterrupt，调用Stop或者NewWithCancel返回的cancel时返回ErrStopped
+func (e *InterruptError) Unwrap() error {
+	if _, ok := e.Signal.(stopSignal); ok {
+		return ErrStopped
+	}
+
+	return ErrInterrupt
+}
+
+// defaultSignals 默认监听的中断信号
+var defaultSignals = []os.Signal{syscall.SIGINT, syscall.SIGTERM, os.Interrupt, syscall.SIGHUP}
+
 // Logger log interface
 type Logger interface {
 	Println(msg ...interface{})
@@ -44,17 +95,165 @@ type Logger interface {
 
 // Runner 声明一个runner
 type Runner struct {
-	complete            chan error       // 有缓冲通道，存放所有任务运行后的结果状态
-	tasks               []func() error   // 执行的任务func,如果func没有错误返回，可以返回nil
-	timeout             time.Duration    // 所有的任务超时时间
-	timeCh              <-chan time.Time // 任务超时通道
-	logger              Logger           // 日志输出实例
-	interrupt           chan os.Signal   // 可以控制强制终止的信号
-	allErrors           map[int]error    // 发生错误的task index对应的错误
-	lastTaskId          int              // 最后一次完成的任务id
-	interruptLastTaskId int              // 当接收到终端信号量时，执行任务的id
+	tasks               []task                                                // 执行的任务队列
+	timeout             time.Duration                                         // 所有的任务超时时间
+	warnAt              time.Duration                                         // 执行多久之后调用timeoutWarning
+	timeoutWarning      func(elapsed time.Duration)                           // 执行时间达到warnAt时调用的回调函数
+	deadline            time.Time                                             // 所有的任务必须完成的时间点，和timeout只有一个生效
+	pauseStopsTimer     bool                                                  // 暂停期间是否停止超时计时
+	logger              Logger                                                // 日志输出实例
+	level               Level                                                 // 输出日志的最低级别
+	interrupt           chan os.Signal                                        // 可以控制强制终止的信号
+	interrupted         chan struct{}                                         // 接收到中断信号后关闭该通道
+	grace               time.Duration                                         // 接收到中断信号后，等待正在执行任务结束的时间
+	signals             []os.Signal                                           // 需要监听的中断信号
+	noSignals           bool                                                  // 是否不监听操作系统的中断信号
+	onInterrupt         func(sig os.Signal)                                   // 接收到中断信号时调用的回调函数
+	signal              os.Signal                                             // 导致中断的信号
+	stopped             bool                                                  // 是否调用了Stop
+	canceled            bool                                                  // 是否已经调用NewWithCancel返回的cancel，之后的所有执行都会立即停止
+	allErrors           map[int]error                                         // 发生错误的task index对应的错误，第一个任务出错时才分配
+	results             map[int]Result                                        // 已经完成的task index对应的执行结果，不按照任务数量预先分配，RunOne等只执行部分任务时只占用已完成任务的内存
+	outputs             map[int][]byte                                        // 设置了WithCaptureOutput时，任务index对应的输出
+	skipped             map[int]string                                        // 被跳过的task index对应的跳过原因
+	depStates           map[string]*depState                                  // 本次执行中带有id的任务的依赖状态
+	async               *asyncRun                                             // 最近一次通过StartAsync异步执行的结果
+	lastTaskId          int                                                   // 最后一次完成的任务id
+	interruptLastTaskId int                                                   // 当接收到终端信号量时，执行任务的id
+	stopOnError         bool                                                  // 任务出错时是否停止执行后续的任务
+	isFatal             func(err error) bool                                  // 判断任务的错误是否需要停止执行后续的任务
+	maxErrors           int                                                   // 允许出错的任务数量
+	checkMaxErrors      bool                                                  // 是否检查出错的任务数量
+	concurrency         int                                                   // 并发执行任务的worker数量，小于等于1时顺序执行
+	queueSize           int                                                   // 执行过程中等待执行的任务队列的大小，为0时不限制
+	queueCond           *sync.Cond                                            // 队列已满时阻塞Add，基于r.mu
+	reverse             bool                                                  // 是否按照添加顺序的倒序执行任务
+	chunkSize           int                                                   // 每执行完多少个任务释放一次已经执行的任务函数
+	cancelOnError       bool                                                  // 并发执行时任意一个任务出错是否取消其他任务的ctx
+	taskTimeout         time.Duration                                         // 单个任务的超时时间
+	baseCtx             context.Context                                       // 没有传入ctx时使用的基础ctx
+	clock               Clock                                                 // runner使用的时钟，默认为真实的时钟
+	requireTasks        bool                                                  // 任务队列为空时Start是否返回ErrNoTasks
+	aggregator          func(errs map[int]error) error                        // 多个任务出错时合并错误的方式
+	startDelay          time.Duration                                         // 开始执行第一个任务之前等待的时间
+	delayExcluded       bool                                                  // startDelay是否不计入超时时间
+	slowThreshold       time.Duration                                         // 慢任务的阈值，为0时不检查
+	onSlowTask          func(id int, name string, d time.Duration)            // 任务执行耗时超过slowThreshold时调用的回调函数
+	idFunc              func(index int, task func() error) string             // 任务在日志以及错误记录中使用的id
+	interval            time.Duration                                         // 两个相邻任务之间的间隔时间
+	repeatEvery         time.Duration                                         // 重复执行所有任务的间隔时间
+	maxRuns             int                                                   // 重复执行的最大次数，0表示不限制
+	runCount            int                                                   // 已经执行的次数
+	limiter             *tokenBucket                                          // 任务执行的限流器
+	state               atomic.Int32                                          // runner当前的状态
+	succeeded           atomic.Int64                                          // 本次执行中执行成功的任务数量
+	failed              atomic.Int64                                          // 本次执行中执行失败的任务数量
+	retryAttempts       int                                                   // 任务最多执行的次数，包括第一次执行
+	retryBackoff        time.Duration                                         // 任务重试的间隔时间
+	backoff             BackoffFunc                                           // 计算任务重试之前的等待时间
+	noPanicRetry        bool                                                  // 任务panic时是否不再重试
+	dryRun              bool                                                  // 是否只输出将要执行的任务，不真正执行
+	progress            chan<- Progress                                       // 任务执行进度的通知通道
+	beforeTask          func(id int, name string)                             // 每个任务执行之前调用的回调函数
+	afterTask           func(id int, name string, err error, d time.Duration) // 每个任务执行之后调用的回调函数
+	subscribers         []func(res Result)                                    // 每个任务以及每次执行结束之后调用的订阅函数
+	middlewares         []Middleware                                          // 包装每个任务的中间件，按照添加的顺序由外到内执行
+	onError             func(id int, name string, err error)                  // 任务出错时调用的回调函数
+	checkpoint          func(id int)                                          // 每个任务执行结束之后调用的回调函数，用于持久化执行进度
+	finalizer           func(err error)                                       // 每次执行结束之后调用的回调函数
+	onFinish            func(report RunReport)                                // 每次执行结束之后调用的汇总回调
+	metrics             Collector                                             // 任务执行指标的收集器
+	tracer              Tracer                                                // 任务执行的链路追踪
+	taskLogger          bool                                                  // 是否为每个任务创建带有任务id前缀的logger
+	captureOutput       bool                                                  // 是否收集每个任务的输出
+	panicHandler        func(id int, recovered interface{}, stack []byte)     // 任务panic时的处理函数
+	panicPolicy         PanicPolicy                                           // 任务panic时的处理策略
+	progressClosed      bool                                                  // 进度通知通道是否已经关闭
+	completed           int                                                   // 当前执行已经完成的任务数量
+	started             int                                                   // 当前执行已经开始执行的任务数量
+	startTime           time.Time                                             // Start开始执行的时间
+	from                int                                                   // 本次执行开始的任务id，通过StartFrom设置
+	reverseN            int                                                   // 本次倒序执行的任务数量，为0时按照添加的顺序执行
+	selector            func(tags []string) bool                              // 本次执行筛选任务标签的条件，为nil时执行所有的任务
+	timedOut            bool                                                  // 最后一次执行是否超时
+	wasInterrupted      bool                                                  // 最后一次执行是否被中断
+	endTime             time.Time                                             // Start结束执行的时间
+	mu                  sync.Mutex                                            // 保护任务执行状态的并发读写
+	gen                 uint64                                                // 执行结果的版本号，每次执行或者冻结执行结果时递增
+	paused              bool                                                  // 是否已经暂停
+	pauseCh             chan struct{}                                         // 调用Pause时关闭
+	resumeCh            chan struct{}                                         // 调用Resume时关闭
+}
+
+// Result 任务执行的结果
+type Result struct {
+	Value    interface{}   // 任务返回的值，通过Add添加的任务为nil
+	Err      error         // 任务返回的错误
+	TaskID   int           // 任务id
+	Duration time.Duration // 任务执行的耗时
+	Attempts int           // 任务执行的次数，包括重试的次数
+}
+
+// Progress 任务执行的进度
+type Progress struct {
+	Completed     int   // 已经完成的任务数量
+	Total         int   // 任务队列中的任务总数，不包括StartStream从通道中获取的任务
+	CurrentTaskID int   // 刚刚完成的任务id
+	Err           error // 刚刚完成的任务返回的错误
+}
+
+// task 队列中的一个任务
+type task struct {
+	key     string                                         // 任务id，通过AddDependent添加的任务才有
+	deps    []string                                       // 依赖的任务id
+	cond    func() bool                                    // 任务执行的条件，返回false时跳过该任务
+	name    string                                         // 任务名称，为空时使用任务index作为名称
+	prio    int                                            // 任务优先级，Start时优先级高的任务先执行
+	prev    prevCond                                       // 根据前一个任务的执行结果决定是否执行
+	fn      func(ctx context.Context) (interface{}, error) // 任务执行的func,如果func没有错误返回，可以返回nil
+	raw     func() error                                   // 通过Add等添加的原始任务，用于WithIDFunc，其他方式添加的任务为nil
+	tags    []string                                       // 任务标签，用于StartMatching筛选需要执行的任务
+	timeout time.Duration                                  // 单个任务的超时时间，为0时使用WithTaskTimeout设置的超时时间
+}
+
+// State runner的状态
+type State int32
+
+const (
+	// StateIdle 还没有开始执行任务
+	StateIdle State = iota
+	// StateRunning 正在执行任务
+	StateRunning
+	// StateDone 任务已经执行结束
+	StateDone
+)
+
+// String 返回状态的名称
+func (s State) String() string {
+	switch s {
+	case StateIdle:
+		return "idle"
+	case StateRunning:
+		return "running"
+	case StateDone:
+		return "done"
+	default:
+		return "unknown"
+	}
 }
 
+// PanicPolicy 任务panic时的处理策略
+type PanicPolicy int
+
+const (
+	// RecoverAndContinue 捕获panic并记录为任务的错误，继续执行后续的任务，默认的策略
+	RecoverAndContinue PanicPolicy = iota
+	// RecoverAndStop 捕获panic，停止执行后续的任务，Start返回该panic对应的PanicError
+	RecoverAndStop
+	// Rethrow 捕获panic并记录日志，停止执行后续的任务，然后在Start中重新panic
+	Rethrow
+)
+
 // Option 采用func Option功能模式为Runner添加参数
 type Option func(r *Runner)
 
@@ -62,9 +261,10 @@ type Option func(r *Runner)
 // 默认创建一个无超时任务的runner
 func New(opts ...Option) *Runner {
 	r := &Runner{
-		complete:  make(chan error, 1),
+		level:     LevelInfo,
 		interrupt: make(chan os.Signal, 1), // 声明一个中断信号
 	}
+	r.queueCond = sync.NewCond(&r.mu)
 
 	// 初始化option
 	for _, o := range opts {
@@ -75,13 +275,245 @@ func New(opts ...Option) *Runner {
 		r.logger = log.New(os.Stdout, "", log.LstdFlags)
 	}
 
+	if r.clock == nil {
+		r.clock = realClock{}
+	}
+
 	return r
 }
 
+// NewWithCancel 创建一个runner，同时返回可以在任意goroutine中取消执行的cancel
+// 调用cancel和调用Stop一样，runner会在下一个任务开始之前停止执行，Start返回ErrInterrupt
+// 和Stop不同的是，调用cancel之后的所有执行都会立即返回ErrInterrupt，cancel可以多次调用
+func NewWithCancel(opts ...Option) (*Runner, context.CancelFunc) {
+	r := New(opts...)
+	return r, func() {
+		r.mu.Lock()
+		r.canceled = true
+		r.mu.Unlock()
+
+		r.Stop()
+	}
+}
+
+// WithRequireTasks 设置任务队列为空时Start返回ErrNoTasks，Validate也会返回ErrNoTasks
+// 适用于任务队列为空说明初始化有问题的场景，不设置时空的任务队列直接返回nil
+// StartStream的任务数量在执行之前无法确定，不受该选项影响
+func WithRequireTasks() Option {
+	return func(r *Runner) {
+		r.requireTasks = true
+	}
+}
+
+// WithBaseContext 设置Start、StartFrom等没有传入ctx的方法使用的基础ctx，默认为context.Background()
+// 超时、取消等都会基于该ctx派生，ctx中的值会传递给所有通过AddCtx添加的任务，例如trace id、租户id等
+// ctx被取消时和StartContext传入的ctx被取消的效果相同，StartContext传入的ctx优先于该ctx
+func WithBaseContext(ctx context.Context) Option {
+	return func(r *Runner) {
+		r.baseCtx = ctx
+	}
+}
+
 // WithTimeout 设置任务超时时间
+// 内部会基于Start传入的ctx派生出一个带有deadline的context，超时后Start返回ErrRunTimeout
+// 超时之后任务中可以通过context.Cause(ctx)获取到ErrRunTimeout，WithTaskTimeout超时时获取到的是ErrTaskTimeout
+// 和WithDeadline同时设置时，后设置的option生效
 func WithTimeout(t time.Duration) Option {
 	return func(r *Runner) {
 		r.timeout = t
+		r.deadline = time.Time{}
+	}
+}
+
+// WithDeadline 设置所有的任务必须完成的时间点，到达该时间点时Start返回ErrRunTimeout
+// 调用Start时t已经过去，Start直接返回ErrRunTimeout，不会执行任何任务
+// 和WithTimeout同时设置时，后设置的option生效
+func WithDeadline(t time.Time) Option {
+	return func(r *Runner) {
+		r.deadline = t
+		r.timeout = 0
+	}
+}
+
+// WithTimeoutWarning 设置执行时间达到at时调用cb，elapsed为已经执行的时间，用于在超时之前提前告警
+// 例如WithTimeout(10*time.Minute)时设置at为8分钟，可以输出已经使用了80%超时时间的日志
+// 执行在at之前结束时不会调用cb，cb在独立的goroutine中调用，cb中的panic会被捕获并记录日志
+func WithTimeoutWarning(at time.Duration, cb func(elapsed time.Duration)) Option {
+	return func(r *Runner) {
+		r.warnAt = at
+		r.timeoutWarning = cb
+	}
+}
+
+// WithInterval 设置两个相邻任务之间的间隔时间，第一个任务之前以及最后一个任务之后不会等待
+// 等待可以被ctx取消或者中断信号打断，等待的时间也会计入WithTimeout设置的超时时间
+func WithInterval(d time.Duration) Option {
+	return func(r *Runner) {
+		r.interval = d
+	}
+}
+
+// WithTaskTimeout 设置单个任务的超时时间，任务超时后记录ErrTaskTimeout，然后继续执行下一个任务
+// 和WithTimeout一起使用时，可以通过errors.Is区分ErrRunTimeout和ErrTaskTimeout，两者都满足errors.Is(err, ErrTimeout)
+func WithTaskTimeout(d time.Duration) Option {
+	return func(r *Runner) {
+		r.taskTimeout = d
+	}
+}
+
+// WithRetry 设置任务出错后的重试，attempts为任务最多执行的次数，backoff为每次重试之间的间隔时间
+// 只有所有的执行都失败时，才会记录最后一次执行的错误
+func WithRetry(attempts int, backoff time.Duration) Option {
+	return func(r *Runner) {
+		r.retryAttempts = attempts
+		r.retryBackoff = backoff
+	}
+}
+
+// WithBackoffStrategy 设置任务重试之前等待时间的计算策略，会覆盖WithRetry设置的固定间隔时间
+// 可以使用内置的ExponentialBackoff、JitteredBackoff
+func WithBackoffStrategy(backoff BackoffFunc) Option {
+	return func(r *Runner) {
+		r.backoff = backoff
+	}
+}
+
+// WithoutPanicRetry 设置任务panic时不再重试
+func WithoutPanicRetry() Option {
+	return func(r *Runner) {
+		r.noPanicRetry = true
+	}
+}
+
+// WithProgress 设置任务执行进度的通知通道，每个任务完成后都会发送一次Progress
+// 发送是非阻塞的，通道已满时会丢弃本次进度，避免阻塞任务的执行
+// 所有任务执行结束后会关闭该通道，调用方可以直接range该通道，因此该runner只会通知第一次执行的进度
+func WithProgress(ch chan<- Progress) Option {
+	return func(r *Runner) {
+		r.progress = ch
+	}
+}
+
+// WithGracefulShutdown 设置接收到中断信号后的优雅退出时间
+// 接收到中断信号后，会等待正在执行的任务结束后再停止执行，超过grace时间后Start直接返回ErrInterrupt
+// 设置之后接收到中断信号时不会取消正在执行任务的ctx，再次接收到中断信号强制退出时才会取消
+// 适用于持有数据库事务等需要提交或者回滚的任务
+func WithGracefulShutdown(grace time.Duration) Option {
+	return func(r *Runner) {
+		r.grace = grace
+	}
+}
+
+// WithSignals 设置需要监听的中断信号，不传入信号时使用默认的SIGINT、SIGTERM、SIGHUP
+func WithSignals(sigs ...os.Signal) Option {
+	return func(r *Runner) {
+		r.signals = sigs
+	}
+}
+
+// WithNoSignals 不监听任何操作系统的中断信号，适用于由宿主程序处理信号的场景
+func WithNoSignals() Option {
+	return func(r *Runner) {
+		r.noSignals = true
+	}
+}
+
+// WithOnInterrupt 设置第一次接收到中断信号或者调用Stop时调用的回调函数，可以在当前任务结束之前执行自己的清理逻辑
+// fn在监听信号的goroutine中同步调用，在run返回ErrInterrupt之前执行，fn执行期间不会停止执行任务，因此fn需要尽快返回
+// 回调函数中的panic会被捕获并记录日志
+func WithOnInterrupt(fn func(sig os.Signal)) Option {
+	return func(r *Runner) {
+		r.onInterrupt = fn
+	}
+}
+
+// WithBeforeTask 设置每个任务执行之前调用的回调函数，可以用于上报指标或者开启tracing span
+// 回调函数中的panic会被捕获并记录日志
+func WithBeforeTask(fn func(id int, name string)) Option {
+	return func(r *Runner) {
+		r.beforeTask = fn
+	}
+}
+
+// WithAfterTask 设置每个任务执行之后调用的回调函数，err为任务返回的错误，d为任务执行的耗时
+// 回调函数中的panic会被捕获并记录日志
+func WithAfterTask(fn func(id int, name string, err error, d time.Duration)) Option {
+	return func(r *Runner) {
+		r.afterTask = fn
+	}
+}
+
+// WithOnError 设置任务出错时立即调用的回调函数，适合在这里接入告警，任务执行成功时不会调用
+// 重试的任务只会在最后一次执行出错之后调用一次，回调函数中的panic会被捕获并记录日志
+func WithOnError(fn func(id int, name string, err error)) Option {
+	return func(r *Runner) {
+		r.onError = fn
+	}
+}
+
+// WithSlowTaskThreshold 设置慢任务的阈值，任务执行耗时超过d时输出一条警告日志，开启重试时包括所有重试的耗时
+// 同时设置了WithOnSlowTask时还会调用对应的回调函数，d<=0时不检查
+func WithSlowTaskThreshold(d time.Duration) Option {
+	return func(r *Runner) {
+		r.slowThreshold = d
+	}
+}
+
+// WithOnSlowTask 设置任务执行耗时超过WithSlowTaskThreshold时调用的回调函数，d为任务的执行耗时
+// 回调函数中的panic会被捕获并记录日志
+func WithOnSlowTask(fn func(id int, name string, d time.Duration)) Option {
+	return func(r *Runner) {
+		r.onSlowTask = fn
+	}
+}
+
+// WithFinalizer 设置每次执行结束之后调用的回调函数，用于关闭连接池、刷新缓冲区等清理工作
+// 无论执行成功、超时还是被中断，fn都会被调用一次，err为Start即将返回的错误
+// 回调函数中的panic会被捕获并记录日志
+func WithFinalizer(fn func(err error)) Option {
+	return func(r *Runner) {
+		r.finalizer = fn
+	}
+}
+
+// WithCheckpoint 设置每个任务执行结束之后调用的回调函数，id为执行结束的任务id，包括执行出错以及被跳过的任务
+// 可以在fn中将执行进度持久化到磁盘或者数据库，进程重启之后通过StartFrom(id+1)继续执行
+// 超时或者强制退出之后结束的任务不会调用fn，回调函数中的panic会被捕获并记录日志
+func WithCheckpoint(fn func(id int)) Option {
+	return func(r *Runner) {
+		r.checkpoint = fn
+	}
+}
+
+// WithSilent 丢弃runner输出的所有日志，适用于作为库嵌入到其他程序中的场景
+// 默认的logger依然会输出到os.Stdout，保持兼容
+func WithSilent() Option {
+	return func(r *Runner) {
+		r.logger = discardLogger{}
+	}
+}
+
+// WithPanicHandler 设置任务panic时的处理函数，recovered为recover得到的值，stack为panic时的堆栈
+// 可以用于输出完整的堆栈、上报Sentry，或者在handler中重新panic，handler中的panic不会被捕获，会导致进程崩溃
+func WithPanicHandler(fn func(id int, recovered interface{}, stack []byte)) Option {
+	return func(r *Runner) {
+		r.panicHandler = fn
+	}
+}
+
+// WithPanicPolicy 设置任务panic时的处理策略，默认为RecoverAndContinue
+// 设置为Rethrow时，任务的panic会在Start中重新抛出，适用于将panic视为程序bug需要快速失败的场景
+func WithPanicPolicy(policy PanicPolicy) Option {
+	return func(r *Runner) {
+		r.panicPolicy = policy
+	}
+}
+
+// WithLogLevel 设置输出日志的最低级别，默认为LevelInfo，每个任务执行的调试日志不会输出
+// 设置为LevelDebug时输出所有的日志
+func WithLogLevel(level Level) Option {
+	return func(r *Runner) {
+		r.level = level
 	}
 }
 
@@ -92,112 +524,2009 @@ func WithLogger(l Logger) Option {
 	}
 }
 
-// Add 将需要执行的任务添加到r.tasks队列中
-func (r *Runner) Add(tasks ...func() error) {
-	r.tasks = append(r.tasks, tasks...)
+// WithStopOnError 设置任务出错时停止执行后续的任务，Start返回该任务的错误
+func WithStopOnError() Option {
+	return func(r *Runner) {
+		r.stopOnError = true
+	}
 }
 
-// run 运行一个个任务,如果出错就返回错误信息
-func (r *Runner) run() (err error) {
-	for k, task := range r.tasks {
-		if r.isInterrupt() {
-			r.interruptLastTaskId = k
-			err = ErrInterrupt
-			return
-		}
+// WithFatalError 设置判断任务错误是否致命的函数，fn返回true时停止执行后续的任务，Start返回该任务的错误
+// fn返回false的错误会被记录，继续执行后续的任务，例如校验失败的错误可以继续执行，认证失败的错误立即停止
+// fn中的panic会被捕获并记录日志，此时视为不致命的错误
+func WithFatalError(fn func(err error) bool) Option {
+	return func(r *Runner) {
+		r.isFatal = fn
+	}
+}
 
-		// 记录任务id
-		r.lastTaskId = k
+// WithErrorThreshold 设置允许出错的任务数量，出错的任务数量超过max时停止执行后续的任务
+// Start返回包装了所有任务错误的ErrTooManyErrors
+func WithErrorThreshold(max int) Option {
+	return func(r *Runner) {
+		r.maxErrors = max
+		r.checkMaxErrors = true
+	}
+}
 
-		r.logger.Println("current run task id: ", k)
-		err = r.doTask(task)
-		if err != nil {
-			r.logger.Println("current task exec occur error: ", err)
-			r.allErrors[k] = err
-			continue
+// WithQueueSize 设置执行过程中等待执行的任务队列的大小，n<=0时不限制
+// 并发执行时，WithConcurrency决定固定的worker数量，n为已经分发但是还没有被worker获取的任务的缓冲区大小，
+// 执行过程中尚未开始执行的任务数量达到n时，Add等会阻塞直到有任务开始执行，StartStream从通道中接收任务的速度
+// 也会受限于worker的处理速度，通道的发送方会被阻塞，以此限制内存以及goroutine的数量
+// 注意：设置之后不能在任务中调用Add，否则所有的worker都在等待队列空闲时会造成死锁
+func WithQueueSize(n int) Option {
+	return func(r *Runner) {
+		if n > 0 {
+			r.queueSize = n
 		}
 	}
+}
 
-	return
+// WithConcurrency 设置并发执行任务的worker数量
+// n<=1时，所有的任务按照顺序依次执行
+func WithConcurrency(n int) Option {
+	return func(r *Runner) {
+		r.concurrency = n
+	}
 }
 
-// doTask 执行每个task，需要捕获每个任务是否出现了panic异常
-// 防止一些个别任务出现了panic,从而导致整个tasks执行全部退出
-func (r *Runner) doTask(task func() error) (err error) {
-	defer func() {
-		if e := recover(); e != nil {
-			r.logger.Println("current task throw panic: ", e)
-			err = fmt.Errorf("current task panic: %v", e)
+// WithCancelOnError 设置并发执行时，任意一个任务出错后立即取消所有任务共享的ctx，不再分发后续的任务
+// 类似于errgroup.WithContext，正在执行的任务需要感知ctx的取消才能提前退出，Start返回第一个出现的错误
+// 只在WithConcurrency(n>1)时生效，顺序执行时可以使用WithStopOnError
+func WithCancelOnError() Option {
+	return func(r *Runner) {
+		r.cancelOnError = true
+	}
+}
+
+// Add 将需要执行的任务添加到r.tasks队列中，每个任务等同于通过AddTask添加一个只设置了Fn的默认Task
+// 可以在任务执行的过程中并发调用，新添加的任务也会被执行
+// 添加nil任务不会panic，执行到该任务时会记录ErrNilTask，需要提前发现时可以使用AddChecked
+func (r *Runner) Add(tasks ...func() error) {
+	ts := make([]task, 0, len(tasks))
+	for _, fn := range tasks {
+		ts = append(ts, task{fn: wrapTask(fn), raw: fn})
+	}
+
+	r.addTasks(ts...)
+}
+
+// AddChecked 将需要执行的任务添加到r.tasks队列中，任意一个任务为nil时返回ErrNilTask，所有的任务都不会被添加
+func (r *Runner) AddChecked(tasks ...func() error) error {
+	for i, fn := range tasks {
+		if fn == nil {
+			return fmt.Errorf("%w: argument %d", ErrNilTask, i)
 		}
-	}()
+	}
 
-	err = task()
+	r.Add(tasks...)
+	return nil
+}
 
-	return
+// AddNamed 将带有名称的任务添加到r.tasks队列中，日志和错误记录中会使用该名称
+func (r *Runner) AddNamed(name string, fn func() error) {
+	r.addTasks(task{name: name, fn: wrapTask(fn), raw: fn})
 }
 
-// GetAllErrors 获取已经完成任务的error
-func (r *Runner) GetAllErrors() map[int]error {
-	return r.allErrors
+// AddConditional 将带有执行条件的任务添加到r.tasks队列中
+// 任务执行之前才会调用cond，cond返回false时跳过该任务，不会记录错误
+func (r *Runner) AddConditional(cond func() bool, fn func() error) {
+	r.addTasks(task{cond: cond, fn: wrapTask(fn), raw: fn})
 }
 
-// GetLastTaskId 获取最后一次完成任务id
-func (r *Runner) GetLastTaskId() int {
-	return r.lastTaskId
+// AddPriority 将带有优先级的任务添加到r.tasks队列中，通过Add等添加的任务优先级为0
+// 每次Start时会按照优先级从高到低对r.tasks进行一次稳定排序，相同优先级的任务保持添加的顺序
+// 排序之后GetAllErrors等返回的任务index为排序之后的执行顺序，执行过程中添加的任务不会再次排序
+func (r *Runner) AddPriority(priority int, fn func() error) {
+	r.addTasks(task{prio: priority, fn: wrapTask(fn), raw: fn})
 }
 
-// GetInterruptLastTaskId 当接收到中断信号量时候，执行任务的id
-func (r *Runner) GetInterruptLastTaskId() int {
-	return r.interruptLastTaskId
+// prevCond 根据前一个任务的执行结果决定是否执行任务的条件
+type prevCond int
+
+const (
+	prevNone      prevCond = iota // 不依赖前一个任务的执行结果
+	prevSucceeded                 // 前一个任务执行成功时才执行
+	prevFailed                    // 前一个任务执行失败时才执行
+)
+
+// AddIfPrevSucceeded 添加一个只有在前一个任务执行成功时才执行的任务，前一个任务指的是执行时r.tasks中的前一个任务
+// 前一个任务出错、被跳过或者没有前一个任务时，该任务会被跳过，跳过的原因可以通过GetSkipReasons获取
+// 设置了WithConcurrency时，该任务会等待前一个任务执行结束之后才开始执行
+func (r *Runner) AddIfPrevSucceeded(fn func() error) {
+	r.addTasks(task{prev: prevSucceeded, fn: wrapTask(fn), raw: fn})
 }
 
-// Start 开始执行所有的任务
-func (r *Runner) Start() error {
-	// 接收系统退出信号
-	signal.Notify(r.interrupt, syscall.SIGINT, syscall.SIGTERM, os.Interrupt, syscall.SIGHUP)
+// AddIfPrevFailed 添加一个只有在前一个任务执行出错时才执行的任务，适用于失败之后的回滚、告警等场景
+// 前一个任务执行成功、被跳过或者没有前一个任务时，该任务会被跳过
+func (r *Runner) AddIfPrevFailed(fn func() error) {
+	r.addTasks(task{prev: prevFailed, fn: wrapTask(fn), raw: fn})
+}
 
-	r.allErrors = make(map[int]error, len(r.tasks)+1)
+// checkPrev 根据前一个任务的执行结果判断任务id为k的任务t是否需要执行，不需要执行时返回跳过的原因
+func (r *Runner) checkPrev(k int, t task) (string, bool) {
+	if t.prev == prevNone {
+		return "", true
+	}
 
-	if r.timeout > 0 {
-		r.timeCh = time.After(r.timeout)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	pos := r.posLocked(k)
+	if pos == 0 {
+		return "no previous task", false
+	}
+
+	prev := r.taskIDLocked(pos - 1)
+	if _, ok := r.skipped[prev]; ok {
+		return "previous task skipped", false
 	}
 
-	// 执行完毕的信号量
-	done := make(chan struct{}, 1)
+	res, ok := r.results[prev]
+	switch {
+	case !ok:
+		return "previous task not executed", false
+	case t.prev == prevSucceeded && res.Err != nil:
+		return "previous task failed", false
+	case t.prev == prevFailed && res.Err == nil:
+		return "previous task succeeded", false
+	}
 
-	// 开启独立goroutine执行任务
-	go func() {
-		defer func() {
-			if e := recover(); e != nil {
-				r.logger.Println("exec task panic: ", e)
-			}
+	return "", true
+}
 
-			close(done)
-		}()
+// AddTagged 将带有标签的任务添加到r.tasks队列中，例如"db"、"cache"、"external"
+// 通过StartMatching可以只执行标签满足条件的任务，Start等依然会执行所有的任务
+func (r *Runner) AddTagged(tags []string, fn func() error) {
+	r.addTasks(task{tags: append([]string(nil), tags...), fn: wrapTask(fn), raw: fn})
+}
 
-		r.complete <- r.run()
-	}()
+// AddCtx 将可以感知context的任务添加到r.tasks队列中
+// 当runner超时或被取消时，正在执行的任务可以通过ctx.Done()感知到
+func (r *Runner) AddCtx(tasks ...func(ctx context.Context) error) {
+	ts := make([]task, 0, len(tasks))
+	for _, fn := range tasks {
+		ts = append(ts, task{fn: wrapCtxTask(fn)})
+	}
 
-	select {
-	case <-r.timeCh:
-		r.logger.Println(ErrTimeout)
-		return ErrTimeout
-	case <-done:
-		err := <-r.complete
-		r.logger.Println("task complete status: ", err)
-		return err
+	r.addTasks(ts...)
+}
+
+// AddResult 将有返回值的任务添加到r.tasks队列中，任务的返回值可以通过GetResults或Results获取
+func (r *Runner) AddResult(tasks ...func() (interface{}, error)) {
+	ts := make([]task, 0, len(tasks))
+	for _, fn := range tasks {
+		ts = append(ts, task{fn: wrapResultTask(fn)})
 	}
+
+	r.addTasks(ts...)
 }
 
-// isInterrupt 检查是否接受到操作系统的中断信号
-// 一旦r.interrupt中可以接收值，就会通知Go Runtime停止接收中断信号，然后返回true
-// 这里如果没有default的话，select是会阻塞的，直到r.interrupt可以接收值为止
-func (r *Runner) isInterrupt() bool {
-	select {
-	case sg := <-r.interrupt: // 是否接受到操作系统的中断信号
-		signal.Stop(r.interrupt)
-		r.logger.Println("received signal: ", sg.String())
+// addTasks 加锁将任务添加到r.tasks队列中，设置了WithQueueSize时，执行过程中队列已满会阻塞
+func (r *Runner) addTasks(ts ...task) {
+	r.mu.Lock()
+	for r.queueFullLocked() {
+		r.queueCond.Wait()
+	}
+	r.tasks = append(r.tasks, ts...)
+	r.mu.Unlock()
+}
+
+// queueFullLocked 设置了WithQueueSize时，执行过程中尚未开始执行的任务数量是否已经达到r.queueSize，调用方需要持有r.mu
+func (r *Runner) queueFullLocked() bool {
+	return r.queueSize > 0 && r.State() == StateRunning && r.pendingLocked() >= r.queueSize
+}
+
+// wakeProducersLocked 有任务开始执行、被跳过或者执行结束时，唤醒因为队列已满而阻塞的Add，调用方需要持有r.mu
+func (r *Runner) wakeProducersLocked() {
+	if r.queueSize > 0 {
+		r.queueCond.Broadcast()
+	}
+}
+
+// InsertAt 将任务插入到r.tasks队列中index的位置，index超出范围时插入到队列的头部或者尾部
+// 插入之后index及之后的任务id都会加1，GetLastTaskId、GetAllErrors等使用的都是插入之后的位置
+// 执行过程中调用时，如果index不大于正在执行的任务id，正在执行的任务会被后移，之后会被再次执行，
+// 因此执行过程中只建议插入到尚未执行的位置
+func (r *Runner) InsertAt(index int, tasks ...func() error) {
+	ts := make([]task, 0, len(tasks))
+	for _, fn := range tasks {
+		ts = append(ts, task{fn: wrapTask(fn), raw: fn})
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
 
+	if index < 0 {
+		index = 0
+	}
+
+	if index > len(r.tasks) {
+		index = len(r.tasks)
+	}
+
+	r.tasks = append(r.tasks[:index], append(ts, r.tasks[index:]...)...)
+}
+
+// RemoveAt 从r.tasks队列中删除index位置的任务，index超出范围时返回false
+// 删除之后index之后的任务id都会减1，之后执行时GetAllErrors等记录的都是删除之后的位置
+func (r *Runner) RemoveAt(index int) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if index < 0 || index >= len(r.tasks) {
+		return false
+	}
+
+	r.tasks = append(r.tasks[:index:index], r.tasks[index+1:]...)
+	return true
+}
+
+// Clear 清空r.tasks队列，和Reset不同的是不会清空上一次执行的状态
+func (r *Runner) Clear() {
+	r.mu.Lock()
+	r.tasks = nil
+	r.mu.Unlock()
+}
+
+// Reset 清空任务队列以及上一次执行的状态，保留timeout、logger等配置，runner的状态重置为StateIdle
+// 正在执行任务时调用Reset会返回ErrRunning
+func (r *Runner) Reset() error {
+	if !r.state.CompareAndSwap(int32(StateDone), int32(StateIdle)) && r.State() != StateIdle {
+		return ErrRunning
+	}
+
+	r.mu.Lock()
+	r.tasks = nil
+	r.allErrors = nil
+	r.results = nil
+	r.skipped = nil
+	r.outputs = nil
+	r.lastTaskId = 0
+	r.interruptLastTaskId = 0
+	r.mu.Unlock()
+
+	return nil
+}
+
+// wrapTask 将func() error适配为任务执行的func，忽略ctx，fn为nil时返回nil
+func wrapTask(fn func() error) func(ctx context.Context) (interface{}, error) {
+	if fn == nil {
+		return nil
+	}
+
+	return func(ctx context.Context) (interface{}, error) {
+		return nil, fn()
+	}
+}
+
+// wrapCtxTask 将func(ctx context.Context) error适配为任务执行的func，fn为nil时返回nil
+func wrapCtxTask(fn func(ctx context.Context) error) func(ctx context.Context) (interface{}, error) {
+	if fn == nil {
+		return nil
+	}
+
+	return func(ctx context.Context) (interface{}, error) {
+		return nil, fn(ctx)
+	}
+}
+
+// wrapResultTask 将func() (interface{}, error)适配为任务执行的func，忽略ctx，fn为nil时返回nil
+func wrapResultTask(fn func() (interface{}, error)) func(ctx context.Context) (interface{}, error) {
+	if fn == nil {
+		return nil
+	}
+
+	return func(ctx context.Context) (interface{}, error) {
+		return fn()
+	}
+}
+
+// taskSource 获取本次执行中第k个执行的任务，ok为false时表示没有更多的任务
+// 等待任务的过程中接收到中断信号或者ctx结束时返回errSourceStopped
+type taskSource func(ctx context.Context, k int) (t task, ok bool, err error)
+
+// errSourceStopped 等待任务的过程中接收到中断信号或者ctx结束
+var errSourceStopped = errors.New("task source stopped")
+
+// sliceSource 从r.tasks队列中获取任务
+// 每次都重新获取任务队列的长度，执行过程中新添加的任务也会被执行
+func (r *Runner) sliceSource(ctx context.Context, k int) (task, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.releaseChunk(k)
+	if k >= r.runLenLocked() {
+		return task{}, false, nil
+	}
+
+	id := r.taskIDLocked(k)
+	if id < 0 || id >= len(r.tasks) {
+		return task{}, false, nil
+	}
+
+	return r.tasks[id], true, nil
+}
+
+// streamSource 从通道中获取任务，通道关闭时表示没有更多的任务
+func (r *Runner) streamSource(tasks <-chan func() error) taskSource {
+	return func(ctx context.Context, k int) (task, bool, error) {
+		select {
+		case fn, ok := <-tasks:
+			if !ok {
+				return task{}, false, nil
+			}
+
+			return task{fn: wrapTask(fn), raw: fn}, true, nil
+		case <-ctx.Done():
+			return task{}, false, errSourceStopped
+		case <-r.interruptedCh():
+			return task{}, false, errSourceStopped
+		}
+	}
+}
+
+// run 运行一个个任务,如果出错就返回错误信息
+// 每个任务执行之前，都会检查是否接收到中断信号以及ctx是否已经取消
+// gen为本次执行的版本号，本次执行超时或者结束之后，写入的执行结果会被丢弃
+func (r *Runner) run(ctx context.Context, gen uint64, next taskSource) (err error) {
+	if r.concurrency > 1 {
+		return r.runConcurrent(ctx, gen, next)
+	}
+
+	from := r.startIndex()
+	for k := from; ; k++ {
+		id := r.taskID(k)
+		if err = r.checkStop(ctx, gen, id); err != nil {
+			return
+		}
+
+		t, ok, e := next(ctx, k)
+		if e != nil {
+			return r.checkStop(ctx, gen, id)
+		}
+
+		if !ok {
+			break
+		}
+
+		if !r.throttle(ctx, k == from) {
+			return r.checkStop(ctx, gen, id)
+		}
+
+		// 记录任务id
+		r.setLastTaskId(gen, id)
+
+		if err = r.runTask(ctx, gen, id, t); err != nil {
+			if e := r.abortErr(err); e != nil {
+				return e
+			}
+		}
+	}
+
+	return r.joinErrors()
+}
+
+// job 分发给worker执行的任务
+type job struct {
+	id   int
+	t    task
+	done chan struct{} // 任务执行结束或者被丢弃之后关闭
+}
+
+// runConcurrent 将任务分发给r.concurrency个worker goroutine并发执行
+// 任务的执行结果依然按照任务原始的index记录到r.allErrors中
+func (r *Runner) runConcurrent(ctx context.Context, gen uint64, next taskSource) (err error) {
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+
+	cancel := context.CancelFunc(func() {})
+	if r.cancelOnError {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	defer cancel()
+
+	jobs := make(chan job, r.queueSize)
+	stop := make(chan struct{}) // 需要停止执行后续任务时关闭该通道
+	for i := 0; i < r.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for j := range jobs {
+				// 已经需要停止时，丢弃和stop同时就绪而被分发的任务，以及在队列中等待的任务
+				select {
+				case <-stop:
+					close(j.done)
+					continue
+				default:
+				}
+
+				if r.isInterrupt() || ctx.Err() != nil {
+					close(j.done)
+					continue
+				}
+
+				e := r.runTask(ctx, gen, j.id, j.t)
+				close(j.done)
+				if e == nil {
+					continue
+				}
+
+				// 设置了cancelOnError时，任意一个任务出错都会取消其他任务
+				if !r.cancelOnError {
+					if e = r.abortErr(e); e == nil {
+						continue
+					}
+				}
+
+				once.Do(func() {
+					firstErr = e
+					close(stop)
+					cancel()
+				})
+			}
+		}()
+	}
+
+	var prevDone chan struct{} // 上一个分发的任务执行结束之后关闭
+	from := r.startIndex()
+dispatch:
+	for k := from; ; k++ {
+		id := r.taskID(k)
+		if err = r.checkStop(ctx, gen, id); err != nil {
+			break
+		}
+
+		t, ok, e := next(ctx, k)
+		if e != nil {
+			err = r.checkStop(ctx, gen, id)
+			break
+		}
+
+		if !ok {
+			break
+		}
+
+		if !r.throttle(ctx, k == from) {
+			err = r.checkStop(ctx, gen, id)
+			break
+		}
+
+		// 根据前一个任务的执行结果决定是否执行的任务，需要等待前一个任务执行结束之后才分发
+		if t.prev != prevNone && prevDone != nil {
+			select {
+			case <-prevDone:
+			case <-stop:
+				break dispatch
+			case <-ctx.Done():
+				err = r.checkStop(ctx, gen, id)
+				break dispatch
+			case <-r.interruptedCh():
+				err = r.checkStop(ctx, gen, id)
+				break dispatch
+			}
+		}
+
+		j := job{id: id, t: t, done: make(chan struct{})}
+		select {
+		case jobs <- j:
+			// 记录最后一次分发的任务id
+			r.setLastTaskId(gen, id)
+			prevDone = j.done
+		case <-stop:
+			break dispatch
+		}
+	}
+
+	close(jobs)
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+
+	if err != nil {
+		return
+	}
+
+	return r.joinErrors()
+}
+
+// checkFatal 调用r.isFatal判断err是否致命，r.isFatal中的panic会被捕获，此时返回false
+func (r *Runner) checkFatal(err error) (fatal bool) {
+	if r.isFatal == nil {
+		return false
+	}
+
+	defer func() {
+		if e := recover(); e != nil {
+			r.log(LevelError, "fatal error func throw panic", "panic", e, "error", err)
+			fatal = false
+		}
+	}()
+
+	return r.isFatal(err)
+}
+
+// abortErr 任务返回err之后，判断是否需要停止执行后续的任务
+// 需要停止时返回Start需要返回的错误，否则返回nil
+func (r *Runner) abortErr(err error) error {
+	if r.stopOnError || r.checkFatal(err) {
+		return err
+	}
+
+	var pe *PanicError
+	if r.panicPolicy != RecoverAndContinue && errors.As(err, &pe) {
+		return pe
+	}
+
+	if r.checkMaxErrors {
+		r.mu.Lock()
+		n := len(r.allErrors)
+		r.mu.Unlock()
+
+		if n > r.maxErrors {
+			r.log(LevelWarn, "too many task errors", "errors", n)
+			// aggregator可能返回nil，此时只返回ErrTooManyErrors
+			if err := r.joinErrors(); err != nil {
+				return fmt.Errorf("%w: %w", ErrTooManyErrors, err)
+			}
+
+			return ErrTooManyErrors
+		}
+	}
+
+	return nil
+}
+
+// runTask 执行任务id为k的任务t，并记录任务的执行结果
+func (r *Runner) runTask(ctx context.Context, gen uint64, k int, t task) (err error) {
+	if r.dryRun {
+		r.dryRunTask(gen, k, t)
+		return nil
+	}
+
+	defer r.saveCheckpoint(gen, k)
+
+	if !r.matchTags(k, t) {
+		r.log(LevelDebug, "skip task id", r.taskFields(k, t)...)
+		r.setSkipped(gen, k, "tags not matched")
+		r.markDependency(gen, t, true)
+		return nil
+	}
+
+	if len(t.deps) > 0 {
+		if dep, ok := r.waitDependencies(ctx, t); !ok {
+			r.log(LevelDebug, "skip task id", r.taskFields(k, t)...)
+			r.setSkipped(gen, k, "dependency not succeeded: "+dep)
+			r.markDependency(gen, t, true)
+			return nil
+		}
+	}
+
+	if reason, ok := r.checkPrev(k, t); !ok {
+		r.log(LevelDebug, "skip task id", r.taskFields(k, t)...)
+		r.setSkipped(gen, k, reason)
+		r.markDependency(gen, t, true)
+		return nil
+	}
+
+	if t.cond != nil && !r.checkCond(k, t) {
+		r.log(LevelDebug, "skip task id", r.taskFields(k, t)...)
+		r.setSkipped(gen, k, "condition not met")
+		r.markDependency(gen, t, true)
+		return nil
+	}
+
+	defer func() {
+		r.markDependency(gen, t, err != nil)
+	}()
+
+	r.markStarted(gen)
+
+	r.log(LevelDebug, "current run task id", r.taskFields(k, t)...)
+	if r.beforeTask != nil {
+		r.safeCall("before task hook", func() {
+			r.beforeTask(k, r.nameOf(k, t))
+		})
+	}
+
+	r.observeStart(k)
+	spanCtx, span := r.startSpan(ctx, k, t)
+	taskCtx, out := r.withOutput(r.withTaskLogger(spanCtx, k, t))
+	start := r.now()
+	value, attempts, err := r.doTask(taskCtx, t.fn, r.timeoutOf(t))
+	d := r.since(start)
+	// 超时或者强制退出之后本次执行已经结束，不再调用回调函数以及上报指标
+	current := r.isCurrent(gen)
+	r.setOutput(gen, k, out)
+	r.endSpan(span, err)
+	if current {
+		r.observeEnd(k, d, err)
+		r.checkSlow(k, t, d)
+	}
+
+	r.handlePanic(k, err)
+	if err != nil {
+		r.log(LevelError, "current task exec occur error", append([]interface{}{"error", err}, r.taskFields(k, t)...)...)
+		if current && r.onError != nil {
+			r.safeCall("on error hook", func() {
+				r.onError(k, r.nameOf(k, t), err)
+			})
+		}
+	}
+
+	res := Result{Value: value, Err: err, TaskID: k, Duration: d, Attempts: attempts}
+	r.setResult(gen, res)
+	if current && r.afterTask != nil {
+		r.safeCall("after task hook", func() {
+			r.afterTask(k, r.nameOf(k, t), err, d)
+		})
+	}
+
+	r.publish(gen, res)
+
+	return err
+}
+
+// skipBefore 设置本次执行开始的任务id，之前的任务视为已经执行成功
+func (r *Runner) skipBefore(index int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.reverseN > 0 {
+		// 倒序执行时，从任务id为index的任务开始倒序执行，index之后的任务视为已经执行成功
+		index = r.reverseN - 1 - index
+	}
+
+	if index < 0 {
+		index = 0
+	}
+
+	if index > r.runLenLocked() {
+		index = r.runLenLocked()
+	}
+
+	r.from = index
+	if r.reverseN > 0 {
+		return
+	}
+
+	for _, t := range r.tasks[:index] {
+		if st := r.depStates[t.key]; t.key != "" && st != nil {
+			close(st.done)
+		}
+	}
+}
+
+// startIndex 获取本次执行开始的执行顺序，没有设置WithReverseOrder时即为开始的任务id
+func (r *Runner) startIndex() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.from
+}
+
+// saveCheckpoint 任务id为k的任务执行结束之后调用r.checkpoint，gen不是当前的版本号时不调用
+func (r *Runner) saveCheckpoint(gen uint64, k int) {
+	if r.checkpoint == nil {
+		return
+	}
+
+	r.mu.Lock()
+	current := gen == r.gen
+	r.mu.Unlock()
+
+	if current {
+		r.safeCall("checkpoint", func() {
+			r.checkpoint(k)
+		})
+	}
+}
+
+// markStarted 记录本次执行已经开始执行的任务数量
+func (r *Runner) markStarted(gen uint64) {
+	r.mu.Lock()
+	if gen == r.gen {
+		r.started++
+		r.wakeProducersLocked()
+	}
+	r.mu.Unlock()
+}
+
+// matchTags 检查任务id为k的任务t的标签是否满足本次执行的selector，selector出现panic时视为不满足
+func (r *Runner) matchTags(k int, t task) (ok bool) {
+	r.mu.Lock()
+	selector := r.selector
+	r.mu.Unlock()
+
+	if selector == nil {
+		return true
+	}
+
+	defer func() {
+		if e := recover(); e != nil {
+			r.log(LevelError, "task selector throw panic", append([]interface{}{"panic", e}, r.taskFields(k, t)...)...)
+			ok = false
+		}
+	}()
+
+	return selector(t.tags)
+}
+
+// checkSlow 任务id为k的任务t执行耗时d超过r.slowThreshold时输出警告日志并调用r.onSlowTask
+func (r *Runner) checkSlow(k int, t task, d time.Duration) {
+	if r.slowThreshold <= 0 || d <= r.slowThreshold {
+		return
+	}
+
+	r.log(LevelWarn, "slow task", append([]interface{}{"duration", d, "threshold", r.slowThreshold}, r.taskFields(k, t)...)...)
+	if r.onSlowTask != nil {
+		r.safeCall("on slow task hook", func() {
+			r.onSlowTask(k, r.nameOf(k, t), d)
+		})
+	}
+}
+
+// checkCond 检查任务的执行条件，cond出现panic时视为条件不满足
+func (r *Runner) checkCond(k int, t task) (ok bool) {
+	defer func() {
+		if e := recover(); e != nil {
+			r.log(LevelError, "task condition throw panic", append([]interface{}{"panic", e}, r.taskFields(k, t)...)...)
+			ok = false
+		}
+	}()
+
+	return t.cond()
+}
+
+// setSkipped 记录被跳过的任务以及跳过的原因
+func (r *Runner) setSkipped(gen uint64, k int, reason string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if gen == r.gen {
+		r.skipped[k] = reason
+		r.wakeProducersLocked()
+	}
+}
+
+// setLastTaskId 记录最后一次执行的任务id
+func (r *Runner) setLastTaskId(gen uint64, k int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if gen == r.gen {
+		r.lastTaskId = k
+	}
+}
+
+// handlePanic 任务panic时调用r.panicHandler
+// r.panicHandler中的panic以unsafePanic继续panic，无论是否设置了WithConcurrency都会导致进程崩溃
+func (r *Runner) handlePanic(k int, err error) {
+	if r.panicHandler == nil {
+		return
+	}
+
+	var pe *PanicError
+	if !errors.As(err, &pe) {
+		return
+	}
+
+	defer func() {
+		if e := recover(); e != nil {
+			panic(unsafePanic{source: "panic handler", value: e})
+		}
+	}()
+
+	r.panicHandler(k, pe.Value, pe.Stack)
+}
+
+// safeCall 执行用户设置的回调函数，捕获回调函数的panic，防止影响任务的执行
+func (r *Runner) safeCall(name string, fn func()) {
+	defer func() {
+		if e := recover(); e != nil {
+			r.log(LevelError, name+" throw panic", "panic", e)
+		}
+	}()
+
+	fn()
+}
+
+// checkStop 检查是否接收到中断信号或者ctx已经结束，k为即将执行的任务id
+func (r *Runner) checkStop(ctx context.Context, gen uint64, k int) error {
+	if r.isInterrupt() {
+		r.mu.Lock()
+		if gen == r.gen {
+			r.interruptLastTaskId = k
+		}
+		r.mu.Unlock()
+
+		return r.interruptErr()
+	}
+
+	if err := ctx.Err(); err != nil {
+		r.log(LevelWarn, "context done before task id", "task_id", r.logIDAt(k), "error", err)
+		return err
+	}
+
+	return nil
+}
+
+// joinErrors 通过WithErrorAggregator设置的方式将所有任务的错误合并为一个error，默认按照任务index的顺序通过errors.Join合并
+// 没有任务出错时返回nil
+func (r *Runner) joinErrors() error {
+	errs := r.GetAllErrors()
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return r.aggregateErrors(errs)
+}
+
+// setResult 记录任务的执行结果以及对应的错误，多个worker会并发写入，需要加锁
+// gen不是当前的版本号时，说明本次执行已经超时或者结束，丢弃该结果
+func (r *Runner) setResult(gen uint64, res Result) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if gen != r.gen {
+		return
+	}
+
+	r.results[res.TaskID] = res
+	if res.Err != nil {
+		// allErrors只在第一个任务出错时才分配，大部分任务执行成功时不会占用额外的内存
+		if r.allErrors == nil {
+			r.allErrors = make(map[int]error)
+		}
+
+		r.allErrors[res.TaskID] = res.Err
+		r.failed.Add(1)
+	} else {
+		r.succeeded.Add(1)
+	}
+
+	r.completed++
+	r.notifyProgress(Progress{
+		Completed:     r.completed,
+		Total:         len(r.tasks),
+		CurrentTaskID: res.TaskID,
+		Err:           res.Err,
+	})
+}
+
+// notifyProgress 非阻塞的发送任务进度，调用方需要持有r.mu
+func (r *Runner) notifyProgress(p Progress) {
+	if r.progress == nil || r.progressClosed {
+		return
+	}
+
+	select {
+	case r.progress <- p:
+	default:
+	}
+}
+
+// closeProgress 所有任务执行结束后关闭进度通知通道
+func (r *Runner) closeProgress() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.progress == nil || r.progressClosed {
+		return
+	}
+
+	r.progressClosed = true
+	close(r.progress)
+}
+
+// doTask 执行每个task，返回任务的值、执行的次数以及最后一次执行的错误，timeout为每一次执行的超时时间
+// 如果设置了重试次数，任务出错后会间隔一段时间重试，直到成功或者达到最大执行次数
+// 设置了r.backoff时，由r.backoff计算每次重试之前的等待时间，否则固定等待r.retryBackoff
+// 任务panic时默认也会重试，除非设置了WithoutPanicRetry，任务函数为nil时直接返回ErrNilTask，不会重试
+func (r *Runner) doTask(ctx context.Context, fn func(ctx context.Context) (interface{}, error), timeout time.Duration) (value interface{}, attempts int, err error) {
+	if fn == nil {
+		return nil, 1, ErrNilTask
+	}
+
+	fn = r.withMiddlewares(fn)
+
+	for attempts < r.retryAttempts || attempts == 0 {
+		if attempts > 0 {
+			backoff := r.retryBackoff
+			if r.backoff != nil {
+				backoff = r.backoff(attempts)
+			}
+
+			r.log(LevelDebug, "retry task after", "backoff", backoff, "attempt", attempts+1)
+			if !r.sleepCtx(ctx, backoff) {
+				return
+			}
+		}
+
+		attempts++
+		value, err = r.attemptTask(ctx, fn, timeout)
+		if err == nil {
+			return
+		}
+
+		var pe *PanicError
+		if r.noPanicRetry && errors.As(err, &pe) {
+			return
+		}
+	}
+
+	return
+}
+
+// sleepCtx 等待d时长，ctx结束或者接收到中断信号时提前返回false
+// runner中所有的等待，包括任务间隔、重试退避以及限流，都需要通过sleepCtx，保证退出时不会被等待阻塞
+func (r *Runner) sleepCtx(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+
+	timeCh, stop := r.after(d)
+	defer stop()
+
+	select {
+	case <-timeCh:
+		return true
+	case <-ctx.Done():
+		return false
+	case <-r.interruptedCh():
+		return false
+	}
+}
+
+// throttle 执行每个任务之前，等待暂停恢复、固定的间隔时间以及获取限流的令牌，first表示是否为本次执行的第一个任务
+// 等待过程中ctx结束或者接收到中断信号时返回false
+func (r *Runner) throttle(ctx context.Context, first bool) bool {
+	return r.waitResume(ctx) && r.waitInterval(ctx, first) && r.waitRateLimit(ctx)
+}
+
+// waitInterval 执行每个任务之前，等待r.interval时长，本次执行的第一个任务之前不等待
+// 等待过程中ctx结束或者接收到中断信号时返回false
+func (r *Runner) waitInterval(ctx context.Context, first bool) bool {
+	if r.interval <= 0 || first {
+		return true
+	}
+
+	return r.sleepCtx(ctx, r.interval)
+}
+
+// attemptTask 执行一次task
+// timeout为单个任务的超时时间，大于0时任务会在独立的goroutine中执行，超时后记录ErrTaskTimeout并继续执行下一个任务
+// 注意：如果任务本身不感知ctx的取消，超时后执行该任务的goroutine会一直运行到任务结束，可能造成goroutine泄露，
+// 建议通过AddCtx添加可以感知ctx的任务，在ctx.Done()时主动退出
+func (r *Runner) attemptTask(ctx context.Context, fn func(ctx context.Context) (interface{}, error), timeout time.Duration) (interface{}, error) {
+	if timeout <= 0 {
+		return r.execTask(ctx, fn)
+	}
+
+	// 超时之后以ErrTaskTimeout为cause取消任务的ctx
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	if r.isRealClock() {
+		var cancelTimeout context.CancelFunc
+		ctx, cancelTimeout = context.WithTimeoutCause(ctx, timeout, ErrTaskTimeout)
+		defer cancelTimeout()
+	}
+
+	timeCh, stop := r.after(timeout)
+	defer stop()
+
+	done := make(chan Result, 1)
+	go func() {
+		value, err := r.execTask(ctx, fn)
+		done <- Result{Value: value, Err: err}
+	}()
+
+	select {
+	case res := <-done:
+		return res.Value, res.Err
+	case <-timeCh:
+		cancel(ErrTaskTimeout)
+		r.log(LevelWarn, "current task exec timeout", "timeout", timeout)
+		return nil, ErrTaskTimeout
+	}
+}
+
+// execTask 执行task，需要捕获每个任务是否出现了panic异常
+// 防止一些个别任务出现了panic,从而导致整个tasks执行全部退出
+func (r *Runner) execTask(ctx context.Context, fn func(ctx context.Context) (interface{}, error)) (value interface{}, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			repanicUnsafe(e)
+			r.log(LevelError, "current task throw panic", "panic", e)
+			err = &PanicError{Value: e, Stack: debug.Stack()}
+		}
+	}()
+
+	value, err = fn(ctx)
+
+	return
+}
+
+// PanicError 任务panic时返回的错误，包含recover得到的值以及panic时的堆栈
+type PanicError struct {
+	Value interface{} // recover得到的值
+	Stack []byte      // panic时的堆栈
+}
+
+// Error 实现error接口，错误信息中包含panic时的堆栈
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("current task panic: %v\n%s", e.Value, e.Stack)
+}
+
+// Unwrap panic的值本身是error时返回该error，使errors.Is和errors.As可以匹配到panic的error
+// panic的值不是error时返回nil
+func (e *PanicError) Unwrap() error {
+	if err, ok := e.Value.(error); ok {
+		return err
+	}
+
+	return nil
+}
+
+// PanicInfo 任务panic的信息
+type PanicInfo struct {
+	Value interface{} // recover得到的值
+	Stack []byte      // panic时的堆栈
+}
+
+// GetPanics 获取已经完成的任务中出现了panic的任务，key为任务index
+// 出现panic的任务依然会记录在GetAllErrors中，可以通过GetPanics区分程序bug导致的panic和预期内的错误
+func (r *Runner) GetPanics() map[int]PanicInfo {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	panics := make(map[int]PanicInfo)
+	for k, err := range r.allErrors {
+		var pe *PanicError
+		if errors.As(err, &pe) {
+			panics[k] = PanicInfo{Value: pe.Value, Stack: pe.Stack}
+		}
+	}
+
+	return panics
+}
+
+// GetAllErrors 获取已经完成任务的error，返回的是一份拷贝，可以在任务执行的过程中并发调用，修改返回值不会影响runner
+func (r *Runner) GetAllErrors() map[int]error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	errs := make(map[int]error, len(r.allErrors))
+	for k, err := range r.allErrors {
+		errs[k] = err
+	}
+
+	return errs
+}
+
+// TaskError 任务id以及对应的错误
+type TaskError struct {
+	ID  int
+	Err error
+}
+
+// SortedErrors 获取已经完成任务的error，按照任务id从小到大排序，便于输出稳定的日志以及对比执行结果
+func (r *Runner) SortedErrors() []TaskError {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	errs := make([]TaskError, 0, len(r.allErrors))
+	for k, err := range r.allErrors {
+		errs = append(errs, TaskError{ID: k, Err: err})
+	}
+
+	sort.Slice(errs, func(i, j int) bool {
+		return errs[i].ID < errs[j].ID
+	})
+
+	return errs
+}
+
+// FlattenedErrors 获取已经完成任务的所有底层错误，按照任务id从小到大排序
+// 任务返回errors.Join等实现了Unwrap() []error的错误时，会递归展开为多个错误，便于统计底层失败的数量
+// 只通过fmt.Errorf("%w")包装了单个错误的错误不会被展开
+func (r *Runner) FlattenedErrors() []error {
+	var errs []error
+	for _, e := range r.SortedErrors() {
+		errs = flattenError(errs, e.Err)
+	}
+
+	return errs
+}
+
+// flattenError 将err递归展开之后追加到errs中
+func flattenError(errs []error, err error) []error {
+	multi, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		return append(errs, err)
+	}
+
+	for _, e := range multi.Unwrap() {
+		if e != nil {
+			errs = flattenError(errs, e)
+		}
+	}
+
+	return errs
+}
+
+// GetNamedErrors 获取已经完成任务的error，key为任务名称，没有名称的任务使用WithIDFunc得到的id作为名称，默认为任务index
+func (r *Runner) GetNamedErrors() map[string]error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	errs := make(map[string]error, len(r.allErrors))
+	for k, err := range r.allErrors {
+		errs[r.taskName(k)] = err
+	}
+
+	return errs
+}
+
+// taskName 获取任务名称，没有名称的任务返回任务id，调用方需要持有r.mu
+func (r *Runner) taskName(k int) string {
+	if k >= 0 && k < len(r.tasks) {
+		return r.nameOf(k, r.tasks[k])
+	}
+
+	return r.formatID(k, task{})
+}
+
+// nameOf 获取任务id为k的任务t的名称，没有名称的任务返回formatID得到的id
+func (r *Runner) nameOf(k int, t task) string {
+	if t.name != "" {
+		return t.name
+	}
+
+	return r.formatID(k, t)
+}
+
+// GetSkipped 获取被跳过的任务id，按照任务id从小到大排序
+func (r *Runner) GetSkipped() []int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ids := make([]int, 0, len(r.skipped))
+	for k := range r.skipped {
+		ids = append(ids, k)
+	}
+
+	sort.Ints(ids)
+
+	return ids
+}
+
+// GetSkipReasons 获取被跳过的任务id以及跳过的原因
+func (r *Runner) GetSkipReasons() map[int]string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	reasons := make(map[int]string, len(r.skipped))
+	for k, reason := range r.skipped {
+		reasons[k] = reason
+	}
+
+	return reasons
+}
+
+// GetResults 获取已经完成任务的返回值
+func (r *Runner) GetResults() map[int]interface{} {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	values := make(map[int]interface{}, len(r.results))
+	for k, res := range r.results {
+		values[k] = res.Value
+	}
+
+	return values
+}
+
+// Results 获取已经完成任务的执行结果，按照任务id从小到大排序
+func (r *Runner) Results() []Result {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	results := make([]Result, 0, len(r.results))
+	for _, res := range r.results {
+		results = append(results, res)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].TaskID < results[j].TaskID
+	})
+
+	return results
+}
+
+// GetAttempts 获取已经完成任务的执行次数
+func (r *Runner) GetAttempts() map[int]int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	attempts := make(map[int]int, len(r.results))
+	for k, res := range r.results {
+		attempts[k] = res.Attempts
+	}
+
+	return attempts
+}
+
+// GetDurations 获取已经完成任务的执行耗时，开启重试时包括所有重试的耗时
+func (r *Runner) GetDurations() map[int]time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	durations := make(map[int]time.Duration, len(r.results))
+	for k, res := range r.results {
+		durations[k] = res.Duration
+	}
+
+	return durations
+}
+
+// SlowestTask 获取执行耗时最长的任务id以及耗时，没有完成的任务时返回-1
+func (r *Runner) SlowestTask() (id int, d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id = -1
+	for k, res := range r.results {
+		if id == -1 || res.Duration > d || (res.Duration == d && k < id) {
+			id, d = k, res.Duration
+		}
+	}
+
+	return
+}
+
+// TotalDuration 获取Start执行的总耗时，正在执行时返回已经执行的时间
+func (r *Runner) TotalDuration() time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.elapsedLocked()
+}
+
+// Elapsed 获取本次执行已经执行的时间，执行结束之后返回本次执行的总耗时，可以在执行的过程中并发调用
+func (r *Runner) Elapsed() time.Duration {
+	return r.TotalDuration()
+}
+
+// ETA 根据已经完成任务的平均耗时以及还没有开始执行的任务数量，估算本次执行剩余的时间
+// 平均耗时为已经执行的时间除以已经完成的任务数量，因此并发执行时也可以得到合理的估算
+// 还没有任务完成时返回0，表示无法估算，可以在执行的过程中并发调用
+func (r *Runner) ETA() time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.completed == 0 {
+		return 0
+	}
+
+	return r.elapsedLocked() / time.Duration(r.completed) * time.Duration(r.pendingLocked())
+}
+
+// elapsedLocked 获取本次执行已经执行的时间，调用方需要持有r.mu
+func (r *Runner) elapsedLocked() time.Duration {
+	if r.startTime.IsZero() {
+		return 0
+	}
+
+	if r.endTime.IsZero() {
+		return r.since(r.startTime)
+	}
+
+	return r.endTime.Sub(r.startTime)
+}
+
+// Len 返回r.tasks队列中的任务数量，可以在任务执行的过程中并发调用
+func (r *Runner) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return len(r.tasks)
+}
+
+// Pending 返回r.tasks队列中本次执行还没有开始执行的任务数量，被跳过的任务视为已经处理
+// 可以在任务执行的过程中并发调用，StartStream从通道中获取的任务不会被统计
+func (r *Runner) Pending() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.pendingLocked()
+}
+
+// pendingLocked 获取本次执行还没有开始执行的任务数量，调用方需要持有r.mu
+func (r *Runner) pendingLocked() int {
+	if n := len(r.tasks) - r.from - r.started - len(r.skipped); n > 0 {
+		return n
+	}
+
+	return 0
+}
+
+// RemainingTasks 获取最后一次执行中没有执行完成的任务id，从小到大排序，被跳过的任务以及StartFrom之前的任务不会返回
+// 超时或者被中断时，正在执行但是没有完成的任务也会返回，可以将这些任务重新加入队列或者通过StartFrom继续执行
+func (r *Runner) RemainingTasks() []int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ids := make([]int, 0)
+	n := r.runLenLocked()
+	for pos := r.from; pos < n; pos++ {
+		k := r.taskIDLocked(pos)
+		if _, ok := r.results[k]; ok {
+			continue
+		}
+
+		if _, ok := r.skipped[k]; ok {
+			continue
+		}
+
+		ids = append(ids, k)
+	}
+
+	// 倒序执行时，执行过程中添加的任务不会被执行
+	for k := n; k < len(r.tasks); k++ {
+		ids = append(ids, k)
+	}
+
+	sort.Ints(ids)
+	return ids
+}
+
+// Succeeded 获取本次执行中已经执行成功的任务数量，可以在执行的过程中并发调用，每次Start时重置
+func (r *Runner) Succeeded() int {
+	return int(r.succeeded.Load())
+}
+
+// Failed 获取本次执行中已经执行失败的任务数量，可以在执行的过程中并发调用，每次Start时重置
+func (r *Runner) Failed() int {
+	return int(r.failed.Load())
+}
+
+// State 获取runner当前的状态，可以在其他goroutine中并发调用
+func (r *Runner) State() State {
+	return State(r.state.Load())
+}
+
+// TimedOut 最后一次执行是否因为WithTimeout或者WithDeadline超时结束，只有单个任务超时时返回false
+// 可以在其他goroutine中并发调用，例如StartAsync之后通过Wait等待结束，再次执行时会被重置
+func (r *Runner) TimedOut() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.timedOut
+}
+
+// Interrupted 最后一次执行是否因为接收到中断信号或者调用Stop结束，可以在其他goroutine中并发调用
+func (r *Runner) Interrupted() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.wasInterrupted
+}
+
+// setOutcome 根据一次执行返回的错误记录是否超时或者被中断
+func (r *Runner) setOutcome(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.timedOut = errors.Is(err, ErrRunTimeout)
+	r.wasInterrupted = errors.Is(err, ErrInterrupt)
+}
+
+// GetLastTaskId 获取最后一次完成任务id
+func (r *Runner) GetLastTaskId() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.lastTaskId
+}
+
+// GetInterruptLastTaskId 当接收到中断信号量时候，执行任务的id
+func (r *Runner) GetInterruptLastTaskId() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.interruptLastTaskId
+}
+
+// Start 开始执行所有的任务，设置了WithBaseContext时基于该ctx执行
+func (r *Runner) Start() error {
+	return r.StartContext(r.baseContext())
+}
+
+// StartContext 开始执行所有的任务，当ctx被取消时，会在下一个任务开始之前停止执行
+// 如果设置了超时时间，会基于ctx派生出一个带有deadline的context
+// 返回ErrRunTimeout时，正在执行的任务可能还在后台运行，但是GetAllErrors、GetLastTaskId等只会返回超时之前已经完成任务的结果
+func (r *Runner) StartContext(ctx context.Context) error {
+	return r.start(ctx, r.sliceSource, r.prepareTasks)
+}
+
+// baseContext 获取没有传入ctx时使用的基础ctx
+func (r *Runner) baseContext() context.Context {
+	if r.baseCtx != nil {
+		return r.baseCtx
+	}
+
+	return context.Background()
+}
+
+// prepareTasks 执行之前按照优先级和依赖关系对r.tasks进行排序
+func (r *Runner) prepareTasks() error {
+	r.mu.Lock()
+	sort.SliceStable(r.tasks, func(i, j int) bool {
+		return r.tasks[i].prio > r.tasks[j].prio
+	})
+	r.mu.Unlock()
+
+	if err := r.prepareReverse(); err != nil {
+		return err
+	}
+
+	return r.prepareDependencies()
+}
+
+// StartFrom 从任务id为index的任务开始执行，之前的任务不会被执行，index超出范围时不会执行任何任务
+// 可以传入上一次执行中断时的GetInterruptLastTaskId，或者WithCheckpoint记录的最后一个id加1，从中断的位置继续执行
+// 之前的任务视为已经执行成功，依赖这些任务的任务会正常执行
+func (r *Runner) StartFrom(index int) error {
+	return r.start(r.baseContext(), r.sliceSource, func() error {
+		if err := r.prepareTasks(); err != nil {
+			return err
+		}
+
+		r.skipBefore(index)
+		return nil
+	})
+}
+
+// RunOne 只执行任务id为index的任务，其他任务不会被执行，适用于调试以及单独复现某一个出错的任务
+// 和Start一样会经过重试、超时、panic捕获等处理，执行结果记录在任务id为index的位置，可以通过GetAllErrors等获取
+// 不会按照优先级和依赖关系排序，index为当前r.tasks中的位置，依赖关系以及AddIfPrevSucceeded等前置条件会被忽略
+// index超出范围时返回ErrNoSuchTask
+func (r *Runner) RunOne(index int) error {
+	if index < 0 || index >= r.Len() {
+		return fmt.Errorf("%w: %d", ErrNoSuchTask, index)
+	}
+
+	return r.start(r.baseContext(), r.oneSource(index), func() error {
+		r.mu.Lock()
+		r.from = index
+		// 依赖关系被忽略，丢弃上一次执行残留的依赖状态
+		r.depStates = nil
+		r.mu.Unlock()
+		return nil
+	})
+}
+
+// oneSource 只提供任务id为index的任务，去掉依赖关系以及前置条件
+func (r *Runner) oneSource(index int) taskSource {
+	return func(ctx context.Context, k int) (task, bool, error) {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		if k != index || index >= len(r.tasks) {
+			return task{}, false, nil
+		}
+
+		t := r.tasks[index]
+		t.deps, t.prev = nil, prevNone
+		return t, true, nil
+	}
+}
+
+// StartMatching 只执行标签满足selector的任务，其他任务记录为跳过，跳过的原因为"tags not matched"
+// 没有标签的任务调用selector时tags为nil，依赖被跳过任务的任务也会被跳过，selector只对本次执行生效
+// 例如冒烟测试时只执行带有"external"标签的任务
+func (r *Runner) StartMatching(selector func(tags []string) bool) error {
+	return r.start(r.baseContext(), r.sliceSource, func() error {
+		if err := r.prepareTasks(); err != nil {
+			return err
+		}
+
+		r.mu.Lock()
+		r.selector = selector
+		r.mu.Unlock()
+		return nil
+	})
+}
+
+// StartStream 从tasks通道中依次获取任务并执行，直到tasks被关闭、ctx被取消或者超时
+// 任务id按照从通道中接收的顺序递增，适用于任务数量不确定或者由上游持续产生任务的场景
+func (r *Runner) StartStream(ctx context.Context, tasks <-chan func() error) error {
+	return r.start(ctx, r.streamSource(tasks), nil)
+}
+
+// start 执行next提供的所有任务，prepare不为nil时，会在执行任务之前调用，返回错误时不执行任何任务
+// 设置了WithRepeat时，每次执行结束之后等待r.repeatEvery再次执行，直到达到最大执行次数、接收到中断信号或者ctx结束
+func (r *Runner) start(ctx context.Context, next taskSource, prepare func() error) (err error) {
+	if !r.state.CompareAndSwap(int32(StateIdle), int32(StateRunning)) &&
+		!r.state.CompareAndSwap(int32(StateDone), int32(StateRunning)) {
+		return ErrRunning
+	}
+	defer func() {
+		r.state.Store(int32(StateDone))
+
+		r.mu.Lock()
+		r.wakeProducersLocked()
+		r.mu.Unlock()
+	}()
+	defer func() {
+		// 重复执行的间隔期间被中断时，runOnce中记录的仍然是上一次执行的结果
+		r.setOutcome(err)
+		r.finalize(err)
+	}()
+	defer r.clearStop()
+
+	// 任务队列为空时直接返回，不监听信号也不启动任何goroutine
+	if prepare != nil && r.requireTasks && r.Len() == 0 {
+		r.log(LevelError, ErrNoTasks.Error())
+		return ErrNoTasks
+	}
+
+	if prepare != nil && r.repeatEvery <= 0 && r.Len() == 0 {
+		gen := r.resetState()
+		r.markEnd()
+		r.log(LevelInfo, "no task to run")
+		r.publish(gen, Result{TaskID: -1})
+		return nil
+	}
+
+	// 接收系统退出信号，重复执行的间隔期间也需要接收
+	defer r.notifySignals()()
+
+	for n := 1; ; n++ {
+		err = r.runOnce(ctx, next, prepare)
+		// err已经赋值，重新panic时finalizer以及onFinish依然可以接收到PanicError
+		r.rethrow(err)
+		if !r.shouldRepeat(ctx, n, err) {
+			return err
+		}
+
+		if err = r.waitRepeat(ctx); err != nil {
+			return err
+		}
+	}
+}
+
+// runOnce 执行一次next提供的所有任务
+func (r *Runner) runOnce(ctx context.Context, next taskSource, prepare func() error) (err error) {
+	// 重置上一次执行的状态，保证同一个runner可以多次调用Start
+	gen := r.resetState()
+	defer func() {
+		r.setOutcome(err)
+		r.publish(gen, Result{TaskID: -1, Err: err, Duration: r.TotalDuration()})
+	}()
+	defer r.markEnd()
+
+	if r.deadlineExceeded() {
+		r.log(LevelWarn, ErrRunTimeout.Error(), "deadline", r.deadline)
+		return ErrRunTimeout
+	}
+
+	if r.dryRun {
+		if err = r.Validate(); err != nil {
+			r.log(LevelError, "validate tasks failed", "error", err)
+			return err
+		}
+	}
+
+	if prepare != nil {
+		if err = prepare(); err != nil {
+			r.log(LevelError, "prepare tasks failed", "error", err)
+			return err
+		}
+	}
+
+	if err = r.waitStartDelay(ctx, true); err != nil {
+		return err
+	}
+
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	if err = r.waitStartDelay(ctx, false); err != nil {
+		return err
+	}
+
+	if stop := r.startTimeoutWarning(); stop != nil {
+		defer stop()
+	}
+
+	// 监听中断信号，接收到信号后关闭r.interrupted，再次接收到信号后关闭forced
+	interrupted := r.interruptedCh()
+	forced := make(chan struct{})
+	watchDone := make(chan struct{})
+	defer close(watchDone)
+	taskCtx, cancelTasks := context.WithCancelCause(ctx)
+	defer cancelTasks(nil)
+	go r.watchInterrupt(interrupted, forced, watchDone, cancelTasks)
+
+	// 开启独立goroutine执行任务，执行完毕之后关闭res.done
+	// 通过关闭通道通知执行结束，超时或者强制退出之后没有接收方时，执行任务的goroutine也不会阻塞
+	res := &runResult{done: make(chan struct{})}
+	done := res.done
+	go func() {
+		defer func() {
+			if e := recover(); e != nil {
+				repanicUnsafe(e)
+				r.log(LevelError, "exec task panic", "panic", e)
+				res.err = fmt.Errorf("exec task panic: %v", e)
+			}
+
+			r.closeProgress()
+			close(done)
+		}()
+
+		res.err = r.run(taskCtx, gen, next)
+	}()
+
+	select {
+	case <-ctx.Done():
+		if isRunTimeout(ctx) {
+			// 超时之后run goroutine中的任务可能还在执行，冻结已经完成任务的执行结果
+			// 返回之前defer的cancel会再次取消ctx，run goroutine在checkStop中感知到之后，不会再开始执行新的任务
+			r.freeze(gen)
+			r.log(LevelWarn, ErrRunTimeout.Error())
+			return ErrRunTimeout
+		}
+
+		// ctx被调用方取消，等待当前任务执行完毕，run会在下一个任务开始之前返回
+		<-done
+		err = res.err
+		r.log(LevelInfo, "task canceled status", "error", err)
+		return err
+	case <-interrupted:
+		return r.waitGraceful(ctx, gen, res, forced)
+	case <-done:
+		err = res.err
+		r.log(LevelInfo, "task complete status", "error", err)
+		return err
+	}
+}
+
+// finalize 调用r.finalizer以及r.onFinish，回调中的panic会被捕获并记录日志
+func (r *Runner) finalize(err error) {
+	if r.finalizer != nil {
+		r.safeCall("finalizer", func() {
+			r.finalizer(err)
+		})
+	}
+
+	if r.onFinish != nil {
+		rep := r.report(err)
+		r.safeCall("finish callback", func() {
+			r.onFinish(rep)
+		})
+	}
+}
+
+// startTimeoutWarning 设置了WithTimeoutWarning时启动告警的定时器，返回停止定时器的函数
+func (r *Runner) startTimeoutWarning() func() bool {
+	if r.warnAt <= 0 || r.timeoutWarning == nil {
+		return nil
+	}
+
+	start := time.Now()
+	timer := time.AfterFunc(r.warnAt, func() {
+		r.log(LevelWarn, "run is approaching timeout", "elapsed", time.Since(start))
+		r.safeCall("timeout warning", func() {
+			r.timeoutWarning(time.Since(start))
+		})
+	})
+
+	return timer.Stop
+}
+
+// withTimeout 根据r.timeout或者r.deadline派生出带有deadline的context，都没有设置时只派生出可以取消的context
+func (r *Runner) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if !r.deadline.IsZero() && r.isRealClock() {
+		return context.WithDeadlineCause(ctx, r.deadline, ErrRunTimeout)
+	}
+
+	if !r.deadline.IsZero() {
+		return r.withClockTimeout(ctx, r.deadline.Sub(r.now()))
+	}
+
+	if r.timeout > 0 && r.pauseStopsTimer {
+		return r.withPausableTimeout(ctx, r.timeout)
+	}
+
+	if r.timeout > 0 && r.isRealClock() {
+		return context.WithTimeoutCause(ctx, r.timeout, ErrRunTimeout)
+	}
+
+	if r.timeout > 0 {
+		return r.withClockTimeout(ctx, r.timeout)
+	}
+
+	return context.WithCancel(ctx)
+}
+
+// isRunTimeout ctx是否因为WithTimeout、WithDeadline或者调用方设置的deadline超时而结束
+func isRunTimeout(ctx context.Context) bool {
+	cause := context.Cause(ctx)
+	return errors.Is(cause, ErrRunTimeout) || errors.Is(cause, context.DeadlineExceeded)
+}
+
+// deadlineExceeded 设置的r.deadline是否已经过去
+func (r *Runner) deadlineExceeded() bool {
+	return !r.deadline.IsZero() && !r.now().Before(r.deadline)
+}
+
+// runResult 执行任务的goroutine的结果，err只有在done关闭之后才可以读取
+// 超时或者强制退出时不会等待done，执行任务的goroutine也不会因为没有接收方而阻塞
+type runResult struct {
+	done chan struct{}
+	err  error
+}
+
+// rethrow 设置了Rethrow策略时，如果任务出现了panic，在调用Start的goroutine中重新panic
+func (r *Runner) rethrow(err error) {
+	var pe *PanicError
+	if r.panicPolicy == Rethrow && errors.As(err, &pe) {
+		r.log(LevelError, "rethrow task panic", "panic", pe.Value, "stack", string(pe.Stack))
+		panic(pe.Value)
+	}
+}
+
+// resetState 重置每一次执行的状态，包括任务的错误、结果、任务id以及interrupt通道
+// 返回本次执行的版本号
+func (r *Runner) resetState() uint64 {
+	r.mu.Lock()
+	r.gen++
+	gen := r.gen
+	r.lastTaskId = 0
+	r.interruptLastTaskId = 0
+	r.interrupted = make(chan struct{})
+	r.allErrors = nil
+	r.results = make(map[int]Result)
+	r.skipped = make(map[int]string)
+	r.outputs = make(map[int][]byte)
+	r.completed = 0
+	r.succeeded.Store(0)
+	r.failed.Store(0)
+	r.started = 0
+	r.from = 0
+	r.reverseN = 0
+	r.selector = nil
+	r.timedOut = false
+	r.wasInterrupted = false
+	r.signal = nil
+	r.startTime = r.now()
+	r.endTime = time.Time{}
+	r.mu.Unlock()
+
+	// 丢弃上一次执行残留的中断信号
+	for drained := false; !drained; {
+		select {
+		case <-r.interrupt:
+		default:
+			drained = true
+		}
+	}
+
+	// Start之前调用了Stop，直接标记为已中断
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stopped {
+		r.signal = stopSignal{}
+		close(r.interrupted)
+	}
+
+	return gen
+}
+
+// freeze 冻结版本号为gen的执行结果，之后写入的执行结果都会被丢弃
+// 用于超时或者强制退出之后，保证GetAllErrors、GetLastTaskId等获取到的结果是一致的
+func (r *Runner) freeze(gen uint64) {
+	r.mu.Lock()
+	if r.gen == gen {
+		r.gen++
+	}
+	r.mu.Unlock()
+}
+
+// notifySignals 没有设置WithNoSignals时，将系统退出信号发送到r.interrupt，返回停止接收信号的函数
+func (r *Runner) notifySignals() func() {
+	if r.noSignals {
+		return func() {}
+	}
+
+	sigs := r.signals
+	if len(sigs) == 0 {
+		sigs = defaultSignals
+	}
+
+	signal.Notify(r.interrupt, sigs...)
+	return func() {
+		signal.Stop(r.interrupt)
+	}
+}
+
+// isCurrent 判断gen是否为当前的版本号，freeze之后返回false
+func (r *Runner) isCurrent(gen uint64) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return gen == r.gen
+}
+
+// waitGraceful 接收到中断信号后，等待正在执行的任务结束，run会在下一个任务开始之前返回InterruptError
+// 如果设置了r.grace，超过等待时间后直接返回InterruptError
+// 等待期间再次接收到中断信号，会立即返回InterruptError，此时正在执行任务的goroutine会继续运行直到任务结束，
+// 可能造成goroutine泄露，等待期间ctx超时时仍然返回ErrRunTimeout，ctx被调用方取消时继续等待
+func (r *Runner) waitGraceful(ctx context.Context, gen uint64, res *runResult, forced <-chan struct{}) error {
+	var timeout <-chan time.Time
+	if r.grace > 0 {
+		timer := time.NewTimer(r.grace)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	ctxDone := ctx.Done()
+	for {
+		select {
+		case <-res.done:
+			err := res.err
+			r.log(LevelInfo, "task interrupt status", "error", err)
+			return err
+		case <-ctxDone:
+			if isRunTimeout(ctx) {
+				r.freeze(gen)
+				r.log(LevelWarn, ErrRunTimeout.Error())
+				return ErrRunTimeout
+			}
+
+			ctxDone = nil
+		case <-timeout:
+			r.freeze(gen)
+			r.log(LevelWarn, "graceful shutdown timeout", "grace", r.grace)
+			return r.interruptErr()
+		case <-forced:
+			r.freeze(gen)
+			r.log(LevelWarn, "received signal again, force exit")
+			return r.interruptErr()
+		}
+	}
+}
+
+// watchInterrupt 监听操作系统的中断信号
+// 第一次接收到信号时关闭interrupted通知run停止执行，第二次接收到信号时关闭forced强制退出
+// 没有设置WithGracefulShutdown时，第一次接收到信号就通过cancel取消正在执行任务的ctx，否则强制退出时才取消
+func (r *Runner) watchInterrupt(interrupted, forced chan struct{}, watchDone <-chan struct{}, cancel context.CancelCauseFunc) {
+	chans := []chan struct{}{interrupted, forced}
+	select {
+	case <-interrupted: // Start之前已经调用了Stop
+		chans = chans[1:]
+		r.notifyInterrupt(stopSignal{})
+		r.cancelInFlight(false, cancel)
+	default:
+	}
+
+	for _, ch := range chans {
+		select {
+		case sg := <-r.interrupt: // 是否接受到操作系统的中断信号
+			r.log(LevelInfo, "received signal", "signal", sg.String())
+			r.mu.Lock()
+			if r.signal == nil {
+				r.signal = sg
+			}
+			r.mu.Unlock()
+
+			if ch == interrupted {
+				r.notifyInterrupt(sg)
+			}
+
+			close(ch)
+			r.cancelInFlight(ch == forced, cancel)
+		case <-watchDone:
+			return
+		}
+	}
+}
+
+// cancelInFlight 接收到中断信号后，以InterruptError为cause取消正在执行任务的ctx，感知ctx的任务可以立即退出
+// 设置了WithGracefulShutdown时，只有forced为true即强制退出时才取消，保证正在执行的任务可以正常结束
+func (r *Runner) cancelInFlight(forced bool, cancel context.CancelCauseFunc) {
+	if r.grace <= 0 || forced {
+		cancel(r.interruptErr())
+	}
+}
+
+// notifyInterrupt 第一次接收到中断信号时调用r.onInterrupt，r.onInterrupt中的panic会被捕获并记录日志
+func (r *Runner) notifyInterrupt(sig os.Signal) {
+	if r.onInterrupt != nil {
+		r.safeCall("on interrupt hook", func() {
+			r.onInterrupt(sig)
+		})
+	}
+}
+
+// Stop 停止执行任务，runner会在下一个任务开始之前停止执行，Start返回ErrInterrupt
+// 和接收到中断信号一样，通过AddCtx添加的正在执行的任务的ctx会被取消，context.Cause(ctx)为InterruptError
+// 可以在其他goroutine中多次调用，在Start之前调用时，下一次Start不会执行任何任务
+func (r *Runner) Stop() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.stopped {
+		return
+	}
+
+	r.stopped = true
+	select {
+	case r.interrupt <- stopSignal{}:
+	default:
+	}
+}
+
+// markEnd 记录Start结束的时间
+func (r *Runner) markEnd() {
+	r.mu.Lock()
+	r.endTime = r.now()
+	r.mu.Unlock()
+}
+
+// clearStop 一次执行结束后，清除Stop的状态，已经调用了NewWithCancel返回的cancel时保持停止的状态
+func (r *Runner) clearStop() {
+	r.mu.Lock()
+	r.stopped = r.canceled
+	r.mu.Unlock()
+}
+
+// stopSignal 调用Stop时发送到r.interrupt的信号
+type stopSignal struct{}
+
+// String 实现os.Signal接口
+func (stopSignal) String() string {
+	return "runner stop"
+}
+
+// Signal 实现os.Signal接口
+func (stopSignal) Signal() {}
+
+// ReceivedSignal 获取导致中断的信号，没有接收到中断信号时返回nil
+func (r *Runner) ReceivedSignal() os.Signal {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.signal
+}
+
+// interruptErr 根据接收到的信号生成InterruptError
+func (r *Runner) interruptErr() error {
+	return &InterruptError{Signal: r.ReceivedSignal()}
+}
+
+// interruptedCh 获取本次执行的中断通知通道
+func (r *Runner) interruptedCh() chan struct{} {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.interrupted
+}
+
+// isInterrupt 检查是否接受到操作系统的中断信号
+// 这里如果没有default的话，select是会阻塞的，直到r.interrupted被关闭为止
+func (r *Runner) isInterrupt() bool {
+	select {
+	case <-r.interruptedCh():
 		return true
 	default:
 		return false
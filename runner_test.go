@@ -1,8 +1,18 @@
 package runner
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log"
 	"os"
+	"os/exec"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
 	"testing"
 	"time"
 )
@@ -55,3 +65,2159 @@ func createTask(id int) func() error {
 PASS
 ok      github.com/go-god/runner        1.264s
 */
+
+// TestRunnerStartContext test cancel runner by context
+func TestRunnerStartContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	p := New(WithLogger(log.New(os.Stdout, "[runner] ", log.LstdFlags)))
+	for i := 0; i < 10; i++ {
+		id := i
+		p.Add(func() error {
+			if id == 3 {
+				cancel()
+			}
+
+			return nil
+		})
+	}
+
+	err := p.StartContext(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got: %v", err)
+	}
+
+	if id := p.GetLastTaskId(); id != 3 {
+		t.Fatalf("expected last task id 3, got: %d", id)
+	}
+}
+
+// TestRunnerAddCtx test context-aware task observe timeout
+func TestRunnerAddCtx(t *testing.T) {
+	p := New(WithTimeout(50 * time.Millisecond))
+
+	canceled := make(chan struct{})
+	p.AddCtx(func(ctx context.Context) error {
+		<-ctx.Done()
+		close(canceled)
+		return ctx.Err()
+	})
+
+	if err := p.Start(); err != ErrRunTimeout {
+		t.Fatalf("expected ErrRunTimeout, got: %v", err)
+	}
+
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Fatal("task did not observe ctx.Done()")
+	}
+}
+
+// TestRunnerStopOnError test stop at the first task error
+func TestRunnerStopOnError(t *testing.T) {
+	errStop := errors.New("stop here")
+	p := New(WithStopOnError())
+	for i := 0; i < 10; i++ {
+		id := i
+		p.Add(func() error {
+			if id == 4 {
+				return errStop
+			}
+
+			return nil
+		})
+	}
+
+	if err := p.Start(); err != errStop {
+		t.Fatalf("expected errStop, got: %v", err)
+	}
+
+	if id := p.GetLastTaskId(); id != 4 {
+		t.Fatalf("expected last task id 4, got: %d", id)
+	}
+
+	if errs := p.GetAllErrors(); len(errs) != 1 || errs[4] != errStop {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+}
+
+// TestRunnerConcurrency test run tasks with worker pool
+func TestRunnerConcurrency(t *testing.T) {
+	p := New(WithConcurrency(4))
+	for i := 0; i < 100; i++ {
+		id := i
+		p.Add(func() error {
+			if id%10 == 0 {
+				return fmt.Errorf("task %d failed", id)
+			}
+
+			return nil
+		})
+	}
+
+	_ = p.Start()
+
+	errs := p.GetAllErrors()
+	if len(errs) != 10 {
+		t.Fatalf("expected 10 errors, got: %d", len(errs))
+	}
+
+	for k, err := range errs {
+		if err.Error() != fmt.Sprintf("task %d failed", k) {
+			t.Fatalf("task %d recorded unexpected error: %v", k, err)
+		}
+	}
+}
+
+// TestRunnerJoinErrors test Start return the joined errors
+func TestRunnerJoinErrors(t *testing.T) {
+	err1 := errors.New("task 1 failed")
+	err3 := errors.New("task 3 failed")
+	p := New()
+	p.Add(
+		func() error { return nil },
+		func() error { return err1 },
+		func() error { return nil },
+		func() error { return err3 },
+	)
+
+	err := p.Start()
+	if !errors.Is(err, err1) || !errors.Is(err, err3) {
+		t.Fatalf("expected joined errors, got: %v", err)
+	}
+
+	if err.Error() != "task 1 failed\ntask 3 failed" {
+		t.Fatalf("unexpected error order: %q", err.Error())
+	}
+}
+
+// TestRunnerTaskTimeout test single task timeout
+func TestRunnerTaskTimeout(t *testing.T) {
+	p := New(WithTaskTimeout(20 * time.Millisecond))
+	p.AddCtx(func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	p.Add(func() error { return nil })
+
+	err := p.Start()
+	if !errors.Is(err, ErrTimeout) {
+		t.Fatalf("expected ErrTimeout, got: %v", err)
+	}
+
+	if errs := p.GetAllErrors(); len(errs) != 1 || errs[0] != ErrTaskTimeout {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+
+	if id := p.GetLastTaskId(); id != 1 {
+		t.Fatalf("expected last task id 1, got: %d", id)
+	}
+}
+
+// TestRunnerTimeoutKinds test run timeout and task timeout distinguished by errors.Is
+func TestRunnerTimeoutKinds(t *testing.T) {
+	wait := func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	p := New(WithSilent(), WithTaskTimeout(20*time.Millisecond), WithTimeout(time.Second))
+	p.AddCtx(wait)
+
+	err := p.Start()
+	if !errors.Is(err, ErrTaskTimeout) || errors.Is(err, ErrRunTimeout) || !errors.Is(err, ErrTimeout) {
+		t.Fatalf("expected ErrTaskTimeout, got: %v", err)
+	}
+
+	if err = p.GetAllErrors()[0]; err != ErrTaskTimeout {
+		t.Fatalf("expected ErrTaskTimeout, got: %v", err)
+	}
+
+	p = New(WithSilent(), WithTaskTimeout(time.Second), WithTimeout(20*time.Millisecond))
+	p.AddCtx(wait)
+
+	err = p.Start()
+	if !errors.Is(err, ErrRunTimeout) || errors.Is(err, ErrTaskTimeout) || !errors.Is(err, ErrTimeout) {
+		t.Fatalf("expected ErrRunTimeout, got: %v", err)
+	}
+}
+
+// TestRunnerResults test get the task results
+func TestRunnerResults(t *testing.T) {
+	p := New()
+	p.AddResult(
+		func() (interface{}, error) { return 1, nil },
+		func() (interface{}, error) { return "two", nil },
+	)
+	p.Add(func() error { return errors.New("no value") })
+
+	if err := p.Start(); err == nil {
+		t.Fatal("expected task error")
+	}
+
+	values := p.GetResults()
+	if values[0] != 1 || values[1] != "two" || values[2] != nil {
+		t.Fatalf("unexpected results: %v", values)
+	}
+
+	results := p.Results()
+	if len(results) != 3 {
+		t.Fatalf("expected 3 results, got: %d", len(results))
+	}
+
+	for i, res := range results {
+		if res.TaskID != i {
+			t.Fatalf("expected task id %d, got: %d", i, res.TaskID)
+		}
+	}
+
+	if results[2].Err == nil {
+		t.Fatal("expected error in result of task 2")
+	}
+}
+
+// TestRunnerRestart test start the same runner multiple times
+func TestRunnerRestart(t *testing.T) {
+	errTask := errors.New("task failed")
+	p := New()
+	for i := 0; i < 5; i++ {
+		id := i
+		p.Add(func() error {
+			if id == 2 {
+				return errTask
+			}
+
+			return nil
+		})
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := p.Start(); !errors.Is(err, errTask) {
+			t.Fatalf("run %d expected errTask, got: %v", i, err)
+		}
+
+		if id := p.GetLastTaskId(); id != 4 {
+			t.Fatalf("run %d expected last task id 4, got: %d", i, id)
+		}
+
+		if errs := p.GetAllErrors(); len(errs) != 1 || errs[2] != errTask {
+			t.Fatalf("run %d unexpected errors: %v", i, errs)
+		}
+
+		if results := p.Results(); len(results) != 5 {
+			t.Fatalf("run %d expected 5 results, got: %d", i, len(results))
+		}
+	}
+}
+
+// TestRunnerReset test reset the runner tasks and state
+func TestRunnerReset(t *testing.T) {
+	p := New(WithStopOnError())
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	p.Add(func() error {
+		close(started)
+		<-release
+		return errors.New("task failed")
+	})
+
+	done := make(chan error, 1)
+	go func() {
+		done <- p.Start()
+	}()
+
+	<-started
+	if err := p.Reset(); err != ErrRunning {
+		t.Fatalf("expected ErrRunning, got: %v", err)
+	}
+
+	close(release)
+	if err := <-done; err == nil {
+		t.Fatal("expected task error")
+	}
+
+	if err := p.Reset(); err != nil {
+		t.Fatalf("reset error: %v", err)
+	}
+
+	if len(p.GetAllErrors()) != 0 || p.GetLastTaskId() != 0 {
+		t.Fatal("expected state cleared after reset")
+	}
+
+	p.Add(func() error { return nil })
+	if err := p.Start(); err != nil {
+		t.Fatalf("expected nil error, got: %v", err)
+	}
+}
+
+// TestRunnerRetry test retry the failed task
+func TestRunnerRetry(t *testing.T) {
+	p := New(WithRetry(3, time.Millisecond))
+
+	calls := 0
+	p.Add(func() error {
+		calls++
+		if calls < 3 {
+			return errors.New("temporary error")
+		}
+
+		return nil
+	})
+	p.Add(func() error {
+		panic("always panic")
+	})
+
+	err := p.Start()
+	if err == nil {
+		t.Fatal("expected panic task error")
+	}
+
+	attempts := p.GetAttempts()
+	if attempts[0] != 3 || attempts[1] != 3 {
+		t.Fatalf("unexpected attempts: %v", attempts)
+	}
+
+	if errs := p.GetAllErrors(); len(errs) != 1 || errs[1] == nil {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+
+	p = New(WithRetry(3, time.Millisecond), WithoutPanicRetry())
+	p.Add(func() error {
+		panic("no retry")
+	})
+
+	_ = p.Start()
+	if attempts := p.GetAttempts(); attempts[0] != 1 {
+		t.Fatalf("expected panic task attempt once, got: %v", attempts)
+	}
+}
+
+// TestRunnerNamedErrors test get errors by task name
+func TestRunnerNamedErrors(t *testing.T) {
+	p := New()
+	p.AddNamed("import-users", func() error { return errors.New("import failed") })
+	p.Add(func() error { return errors.New("anonymous failed") })
+
+	_ = p.Start()
+
+	errs := p.GetNamedErrors()
+	if len(errs) != 2 || errs["import-users"] == nil || errs["1"] == nil {
+		t.Fatalf("unexpected named errors: %v", errs)
+	}
+}
+
+// TestRunnerProgress test receive the progress of tasks
+func TestRunnerProgress(t *testing.T) {
+	ch := make(chan Progress, 10)
+	p := New(WithProgress(ch))
+	for i := 0; i < 5; i++ {
+		p.Add(func() error { return nil })
+	}
+
+	if err := p.Start(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	n := 0
+	for pg := range ch {
+		n++
+		if pg.Completed != n || pg.Total != 5 || pg.CurrentTaskID != n-1 {
+			t.Fatalf("unexpected progress: %+v", pg)
+		}
+	}
+
+	if n != 5 {
+		t.Fatalf("expected 5 progress, got: %d", n)
+	}
+}
+
+// TestRunnerGracefulShutdown test wait in-flight task after interrupt
+func TestRunnerGracefulShutdown(t *testing.T) {
+	p := New(WithGracefulShutdown(time.Second))
+
+	started := make(chan struct{})
+	finished := false
+	p.Add(func() error {
+		close(started)
+		time.Sleep(50 * time.Millisecond)
+		finished = true
+		return nil
+	})
+	p.Add(func() error { return nil })
+
+	go func() {
+		<-started
+		p.interrupt <- syscall.SIGTERM
+	}()
+
+	if err := p.Start(); !errors.Is(err, ErrInterrupt) {
+		t.Fatalf("expected ErrInterrupt, got: %v", err)
+	}
+
+	if !finished {
+		t.Fatal("expected in-flight task finished")
+	}
+
+	if id := p.GetInterruptLastTaskId(); id != 1 {
+		t.Fatalf("expected interrupt task id 1, got: %d", id)
+	}
+
+	// 超过优雅退出时间，直接返回ErrInterrupt
+	p = New(WithGracefulShutdown(20 * time.Millisecond))
+	release := make(chan struct{})
+	defer close(release)
+
+	started = make(chan struct{})
+	p.Add(func() error {
+		close(started)
+		<-release
+		return nil
+	})
+
+	go func() {
+		<-started
+		p.interrupt <- syscall.SIGTERM
+	}()
+
+	start := time.Now()
+	if err := p.Start(); !errors.Is(err, ErrInterrupt) {
+		t.Fatalf("expected ErrInterrupt, got: %v", err)
+	}
+
+	if time.Since(start) > time.Second {
+		t.Fatal("expected return after grace period")
+	}
+}
+
+// TestRunnerInterruptTimeout test the run timeout is still enforced after an interrupt
+func TestRunnerInterruptTimeout(t *testing.T) {
+	for _, opt := range []Option{WithSilent(), WithGracefulShutdown(time.Hour)} {
+		p := New(WithSilent(), WithTimeout(50*time.Millisecond), opt)
+		started := make(chan struct{})
+		p.Add(func() error {
+			close(started)
+			time.Sleep(400 * time.Millisecond)
+			return nil
+		})
+
+		go func() {
+			<-started
+			p.interrupt <- syscall.SIGTERM
+		}()
+
+		start := time.Now()
+		if err := p.Start(); !errors.Is(err, ErrRunTimeout) {
+			t.Fatalf("expected ErrRunTimeout, got: %v", err)
+		}
+
+		if time.Since(start) > 300*time.Millisecond || !p.TimedOut() {
+			t.Fatal("expected return at the deadline")
+		}
+	}
+}
+
+// TestRunnerInterruptCancelsTask test an interrupt cancels the ctx of an in-flight task
+func TestRunnerInterruptCancelsTask(t *testing.T) {
+	p := New(WithSilent())
+	started := make(chan struct{})
+	var cause error
+	p.AddCtx(func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		cause = context.Cause(ctx)
+		return ctx.Err()
+	})
+	p.Add(func() error { return nil })
+
+	go func() {
+		<-started
+		p.interrupt <- syscall.SIGTERM
+	}()
+
+	start := time.Now()
+	if err := p.Start(); !errors.Is(err, ErrInterrupt) {
+		t.Fatalf("expected ErrInterrupt, got: %v", err)
+	}
+
+	if !errors.Is(cause, ErrInterrupt) || time.Since(start) > time.Second {
+		t.Fatalf("expected task canceled by interrupt, got: %v", cause)
+	}
+
+	if errs := p.GetAllErrors(); !errors.Is(errs[0], context.Canceled) || len(errs) != 1 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+}
+
+// TestRunnerCancelCause test context.Cause inside tasks for each termination path
+func TestRunnerCancelCause(t *testing.T) {
+	causeOf := func(p *Runner, trigger func()) error {
+		started := make(chan struct{})
+		causes := make(chan error, 1)
+		p.AddCtx(func(ctx context.Context) error {
+			close(started)
+			<-ctx.Done()
+			causes <- context.Cause(ctx)
+			return ctx.Err()
+		})
+
+		go func() {
+			<-started
+			trigger()
+		}()
+
+		_ = p.Start()
+		return <-causes
+	}
+
+	nothing := func() {}
+	if cause := causeOf(New(WithSilent(), WithTimeout(20*time.Millisecond)), nothing); !errors.Is(cause, ErrRunTimeout) {
+		t.Fatalf("expected ErrRunTimeout, got: %v", cause)
+	}
+
+	if cause := causeOf(New(WithSilent(), WithTaskTimeout(20*time.Millisecond)), nothing); !errors.Is(cause, ErrTaskTimeout) {
+		t.Fatalf("expected ErrTaskTimeout, got: %v", cause)
+	}
+
+	p := New(WithSilent())
+	if cause := causeOf(p, p.Stop); !errors.Is(cause, ErrStopped) || !errors.Is(cause, ErrInterrupt) {
+		t.Fatalf("expected ErrStopped, got: %v", cause)
+	}
+
+	p = New(WithSilent())
+	cause := causeOf(p, func() { p.interrupt <- syscall.SIGTERM })
+	var ie *InterruptError
+	if !errors.Is(cause, ErrInterrupt) || errors.Is(cause, ErrStopped) || !errors.As(cause, &ie) || ie.Signal != syscall.SIGTERM {
+		t.Fatalf("expected InterruptError with SIGTERM, got: %v", cause)
+	}
+}
+
+// TestRunnerDoubleInterrupt test force exit after the second signal
+func TestRunnerDoubleInterrupt(t *testing.T) {
+	p := New(WithGracefulShutdown(time.Hour))
+	release := make(chan struct{})
+	defer close(release)
+
+	started := make(chan struct{})
+	p.Add(func() error {
+		close(started)
+		<-release
+		return nil
+	})
+
+	go func() {
+		<-started
+		p.interrupt <- syscall.SIGINT
+		p.interrupt <- syscall.SIGINT
+	}()
+
+	start := time.Now()
+	if err := p.Start(); !errors.Is(err, ErrInterrupt) {
+		t.Fatalf("expected ErrInterrupt, got: %v", err)
+	}
+
+	if time.Since(start) > time.Second {
+		t.Fatal("expected force exit after the second signal")
+	}
+}
+
+// TestRunnerWithSignals test custom interrupt signals
+func TestRunnerWithSignals(t *testing.T) {
+	p := New(WithSignals(syscall.SIGHUP))
+	started := make(chan struct{})
+	release := make(chan struct{})
+	p.Add(func() error {
+		close(started)
+		<-release
+		return nil
+	})
+	p.Add(func() error { return nil })
+
+	go func() {
+		<-started
+		proc, _ := os.FindProcess(os.Getpid())
+		_ = proc.Signal(syscall.SIGHUP)
+		time.Sleep(20 * time.Millisecond)
+		close(release)
+	}()
+
+	if err := p.Start(); !errors.Is(err, ErrInterrupt) {
+		t.Fatalf("expected ErrInterrupt, got: %v", err)
+	}
+
+	p = New(WithNoSignals())
+	p.Add(func() error { return nil })
+	if err := p.Start(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// TestRunnerReceivedSignal test get the signal caused interrupt
+func TestRunnerReceivedSignal(t *testing.T) {
+	p := New(WithNoSignals())
+	started := make(chan struct{})
+	release := make(chan struct{})
+	p.Add(func() error {
+		close(started)
+		<-release
+		return nil
+	})
+	p.Add(func() error { return nil })
+
+	go func() {
+		<-started
+		p.interrupt <- syscall.SIGTERM
+		time.Sleep(20 * time.Millisecond)
+		close(release)
+	}()
+
+	err := p.Start()
+
+	var ie *InterruptError
+	if !errors.As(err, &ie) || ie.Signal != syscall.SIGTERM {
+		t.Fatalf("expected InterruptError with SIGTERM, got: %v", err)
+	}
+
+	if sg := p.ReceivedSignal(); sg != syscall.SIGTERM {
+		t.Fatalf("expected SIGTERM, got: %v", sg)
+	}
+}
+
+// TestRunnerStop test stop the runner programmatically
+func TestRunnerStop(t *testing.T) {
+	p := New(WithNoSignals())
+	for i := 0; i < 10; i++ {
+		id := i
+		p.Add(func() error {
+			if id == 2 {
+				p.Stop()
+				p.Stop()
+				time.Sleep(20 * time.Millisecond)
+			}
+
+			return nil
+		})
+	}
+
+	if err := p.Start(); !errors.Is(err, ErrInterrupt) {
+		t.Fatalf("expected ErrInterrupt, got: %v", err)
+	}
+
+	if id := p.GetLastTaskId(); id != 2 {
+		t.Fatalf("expected last task id 2, got: %d", id)
+	}
+
+	// Start之前调用Stop，不会执行任何任务
+	p = New(WithNoSignals())
+	executed := false
+	p.Add(func() error {
+		executed = true
+		return nil
+	})
+
+	p.Stop()
+	if err := p.Start(); !errors.Is(err, ErrInterrupt) {
+		t.Fatalf("expected ErrInterrupt, got: %v", err)
+	}
+
+	if executed {
+		t.Fatal("expected no task executed")
+	}
+
+	// Stop只对下一次执行生效
+	if err := p.Start(); err != nil || !executed {
+		t.Fatalf("expected task executed, got: %v", err)
+	}
+}
+
+// TestRunnerAddDuringStart test add tasks while the runner is running, run with -race
+func TestRunnerAddDuringStart(t *testing.T) {
+	p := New()
+	started := make(chan struct{})
+	added := make(chan struct{})
+	p.Add(func() error {
+		close(started)
+		<-added
+		return nil
+	})
+
+	go func() {
+		<-started
+		for i := 0; i < 100; i++ {
+			p.Add(func() error { return nil })
+		}
+
+		close(added)
+	}()
+
+	if err := p.Start(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if n := len(p.Results()); n != 101 {
+		t.Fatalf("expected 101 results, got: %d", n)
+	}
+}
+
+// TestRunnerStartStream test run tasks from a channel
+func TestRunnerStartStream(t *testing.T) {
+	tasks := make(chan func() error)
+	go func() {
+		defer close(tasks)
+
+		for i := 0; i < 10; i++ {
+			id := i
+			tasks <- func() error {
+				if id == 5 {
+					return errors.New("task 5 failed")
+				}
+
+				return nil
+			}
+		}
+	}()
+
+	p := New()
+	if err := p.StartStream(context.Background(), tasks); err == nil {
+		t.Fatal("expected task 5 error")
+	}
+
+	if id := p.GetLastTaskId(); id != 9 {
+		t.Fatalf("expected last task id 9, got: %d", id)
+	}
+
+	if errs := p.GetAllErrors(); len(errs) != 1 || errs[5] == nil {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+
+	// 通道没有关闭时，ctx取消后停止执行
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+
+	if err := New().StartStream(ctx, make(chan func() error)); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got: %v", err)
+	}
+}
+
+// TestRunnerDurations test task durations
+func TestRunnerDurations(t *testing.T) {
+	p := New(WithRetry(2, 0))
+	p.Add(func() error { return nil })
+
+	calls := 0
+	p.Add(func() error {
+		calls++
+		time.Sleep(20 * time.Millisecond)
+		if calls == 1 {
+			return errors.New("retry once")
+		}
+
+		return nil
+	})
+
+	if id, _ := p.SlowestTask(); id != -1 {
+		t.Fatalf("expected no slowest task before start, got: %d", id)
+	}
+
+	if err := p.Start(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if n := len(p.GetDurations()); n != 2 {
+		t.Fatalf("expected 2 durations, got: %d", n)
+	}
+
+	id, d := p.SlowestTask()
+	if id != 1 || d < 40*time.Millisecond {
+		t.Fatalf("expected slowest task 1 including retry, got: %d %v", id, d)
+	}
+
+	if total := p.TotalDuration(); total < d {
+		t.Fatalf("expected total duration >= %v, got: %v", d, total)
+	}
+}
+
+// TestRunnerTaskHooks test before and after task hooks
+func TestRunnerTaskHooks(t *testing.T) {
+	var before, after []string
+	p := New(
+		WithBeforeTask(func(id int, name string) {
+			before = append(before, name)
+			panic("before hook panic")
+		}),
+		WithAfterTask(func(id int, name string, err error, d time.Duration) {
+			after = append(after, fmt.Sprintf("%s:%v", name, err))
+		}),
+	)
+	p.AddNamed("first", func() error { return nil })
+	p.Add(func() error { return errors.New("failed") })
+
+	_ = p.Start()
+
+	if fmt.Sprint(before) != "[first 1]" {
+		t.Fatalf("unexpected before hooks: %v", before)
+	}
+
+	if fmt.Sprint(after) != "[first:<nil> 1:failed]" {
+		t.Fatalf("unexpected after hooks: %v", after)
+	}
+}
+
+// TestRunnerErrorThreshold test stop after too many errors
+func TestRunnerErrorThreshold(t *testing.T) {
+	p := New(WithErrorThreshold(2))
+	for i := 0; i < 10; i++ {
+		id := i
+		p.Add(func() error {
+			if id%2 == 1 {
+				return fmt.Errorf("task %d failed", id)
+			}
+
+			return nil
+		})
+	}
+
+	err := p.Start()
+	if !errors.Is(err, ErrTooManyErrors) {
+		t.Fatalf("expected ErrTooManyErrors, got: %v", err)
+	}
+
+	if id := p.GetLastTaskId(); id != 5 {
+		t.Fatalf("expected last task id 5, got: %d", id)
+	}
+
+	if n := len(p.GetAllErrors()); n != 3 {
+		t.Fatalf("expected 3 errors, got: %d", n)
+	}
+}
+
+// TestRunnerConditional test skip task by condition
+func TestRunnerConditional(t *testing.T) {
+	p := New()
+	executed := 0
+	p.AddConditional(func() bool { return true }, func() error {
+		executed++
+		return nil
+	})
+	p.AddConditional(func() bool { return false }, func() error {
+		executed++
+		return nil
+	})
+	p.AddConditional(func() bool { panic("bad condition") }, func() error {
+		executed++
+		return nil
+	})
+
+	if err := p.Start(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if executed != 1 {
+		t.Fatalf("expected 1 task executed, got: %d", executed)
+	}
+
+	if skipped := p.GetSkipped(); fmt.Sprint(skipped) != "[1 2]" {
+		t.Fatalf("unexpected skipped: %v", skipped)
+	}
+}
+
+// TestRunnerState test the state transitions around Start
+func TestRunnerState(t *testing.T) {
+	p := New()
+	if s := p.State(); s != StateIdle {
+		t.Fatalf("expected idle, got: %v", s)
+	}
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	p.Add(func() error {
+		close(started)
+		<-release
+		return nil
+	})
+
+	done := make(chan error, 1)
+	go func() {
+		done <- p.Start()
+	}()
+
+	<-started
+	if s := p.State(); s != StateRunning {
+		t.Fatalf("expected running, got: %v", s)
+	}
+
+	close(release)
+	<-done
+	if s := p.State(); s != StateDone {
+		t.Fatalf("expected done, got: %v", s)
+	}
+
+	if err := p.Reset(); err != nil || p.State() != StateIdle {
+		t.Fatalf("expected idle after reset, got: %v %v", p.State(), err)
+	}
+}
+
+// TestRunnerTimeoutPartialResults test results are frozen after timeout, run with -race
+func TestRunnerTimeoutPartialResults(t *testing.T) {
+	p := New(WithTimeout(50 * time.Millisecond))
+	p.Add(func() error { return errors.New("task 0 failed") })
+
+	finished := make(chan struct{})
+	p.Add(func() error {
+		defer close(finished)
+		time.Sleep(100 * time.Millisecond)
+		return errors.New("task 1 failed")
+	})
+	p.Add(func() error { return errors.New("task 2 failed") })
+
+	if err := p.Start(); err != ErrRunTimeout {
+		t.Fatalf("expected ErrRunTimeout, got: %v", err)
+	}
+
+	<-finished
+	time.Sleep(10 * time.Millisecond)
+
+	if errs := p.GetAllErrors(); len(errs) != 1 || errs[0] == nil {
+		t.Fatalf("unexpected errors after timeout: %v", errs)
+	}
+
+	if id := p.GetLastTaskId(); id != 1 {
+		t.Fatalf("expected last task id 1, got: %d", id)
+	}
+
+	if n := len(p.Results()); n != 1 {
+		t.Fatalf("expected 1 result, got: %d", n)
+	}
+}
+
+// TestRunnerPanicHandler test handle panic with stack
+func TestRunnerPanicHandler(t *testing.T) {
+	var (
+		panicID    = -1
+		panicValue interface{}
+		panicStack []byte
+	)
+
+	p := New(WithPanicHandler(func(id int, recovered interface{}, stack []byte) {
+		panicID, panicValue, panicStack = id, recovered, stack
+	}))
+	p.Add(func() error { return nil })
+	p.Add(func() error { panic("boom") })
+	_ = p.Start()
+
+	if panicID != 1 || panicValue != "boom" || len(panicStack) == 0 {
+		t.Fatalf("unexpected panic handler args: %d %v", panicID, panicValue)
+	}
+
+	var pe *PanicError
+	if err := p.GetAllErrors()[1]; !errors.As(err, &pe) || pe.Value != "boom" {
+		t.Fatalf("expected PanicError, got: %v", err)
+	}
+
+	if !strings.Contains(pe.Error(), "runtime/debug.Stack") {
+		t.Fatalf("expected stack in error message: %s", pe.Error())
+	}
+}
+
+// TestRunnerPanicHandlerPanic test a panic in the panic handler crashes the process in both modes
+func TestRunnerPanicHandlerPanic(t *testing.T) {
+	if mode := os.Getenv("RUNNER_HANDLER_CRASH"); mode != "" {
+		opts := []Option{WithSilent(), WithPanicHandler(func(id int, recovered interface{}, stack []byte) {
+			panic("again")
+		})}
+		if mode == "concurrent" {
+			opts = append(opts, WithConcurrency(2))
+		}
+
+		p := New(opts...)
+		p.Add(func() error { panic("boom") })
+		_ = p.Start()
+		return
+	}
+
+	for _, mode := range []string{"sequential", "concurrent"} {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestRunnerPanicHandlerPanic$")
+		cmd.Env = append(os.Environ(), "RUNNER_HANDLER_CRASH="+mode)
+		out, err := cmd.CombinedOutput()
+
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) || !strings.Contains(string(out), "panic handler panic: again") {
+			t.Fatalf("expected process crashed in %s mode, got: %v\n%s", mode, err, out)
+		}
+	}
+}
+
+// TestRunnerPanicPolicy test stop or rethrow on task panic
+func TestRunnerPanicPolicy(t *testing.T) {
+	newRunner := func(policy PanicPolicy) *Runner {
+		p := New(WithPanicPolicy(policy))
+		p.Add(func() error { panic("boom") })
+		p.Add(func() error { return nil })
+		return p
+	}
+
+	p := newRunner(RecoverAndContinue)
+	if err := p.Start(); err == nil || len(p.Results()) != 2 {
+		t.Fatalf("expected continue after panic, got: %v", err)
+	}
+
+	p = newRunner(RecoverAndStop)
+	var pe *PanicError
+	if err := p.Start(); !errors.As(err, &pe) || len(p.Results()) != 1 {
+		t.Fatalf("expected stop after panic, got: %v", err)
+	}
+
+	p = newRunner(Rethrow)
+	defer func() {
+		if e := recover(); e != "boom" {
+			t.Fatalf("expected rethrow boom, got: %v", e)
+		}
+	}()
+
+	_ = p.Start()
+	t.Fatal("expected Start to panic")
+}
+
+// TestRunnerRethrowFinalizer test the finalizer and finish callback receive the PanicError on rethrow
+func TestRunnerRethrowFinalizer(t *testing.T) {
+	var (
+		finalErr error
+		rep      RunReport
+	)
+
+	p := New(WithSilent(), WithPanicPolicy(Rethrow), WithFinalizer(func(err error) {
+		finalErr = err
+	}), WithOnFinish(func(report RunReport) {
+		rep = report
+	}))
+	p.Add(func() error { panic("boom") })
+
+	func() {
+		defer func() {
+			if e := recover(); e != "boom" {
+				t.Fatalf("expected rethrow boom, got: %v", e)
+			}
+		}()
+
+		_ = p.Start()
+	}()
+
+	var pe *PanicError
+	if !errors.As(finalErr, &pe) || pe.Value != "boom" {
+		t.Fatalf("expected PanicError in finalizer, got: %v", finalErr)
+	}
+
+	if len(rep.Failed) != 1 || rep.Total != 1 {
+		t.Fatalf("unexpected report: %+v", rep)
+	}
+}
+
+// TestRunnerInterval test pause between tasks
+func TestRunnerInterval(t *testing.T) {
+	p := New(WithInterval(30 * time.Millisecond))
+	for i := 0; i < 3; i++ {
+		p.Add(func() error { return nil })
+	}
+
+	start := time.Now()
+	if err := p.Start(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if d := time.Since(start); d < 60*time.Millisecond || d > 90*time.Millisecond+time.Second {
+		t.Fatalf("unexpected duration with interval: %v", d)
+	}
+
+	// 等待可以被中断
+	p = New(WithInterval(time.Hour), WithNoSignals())
+	p.Add(func() error { return nil })
+	p.Add(func() error { return nil })
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		p.Stop()
+	}()
+
+	start = time.Now()
+	if err := p.Start(); !errors.Is(err, ErrInterrupt) || time.Since(start) > time.Second {
+		t.Fatalf("expected interval interrupted, got: %v", err)
+	}
+}
+
+// TestRunnerInsertAt test insert tasks at an index, out of range indexes are clamped
+func TestRunnerInsertAt(t *testing.T) {
+	var order []string
+	record := func(s string) func() error {
+		return func() error {
+			order = append(order, s)
+			return nil
+		}
+	}
+
+	p := New(WithSilent())
+	p.Add(record("b"), record("d"))
+	p.InsertAt(1, record("c"))
+	p.InsertAt(-5, record("a"))
+	p.InsertAt(100, record("e"))
+	if err := p.Start(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := strings.Join(order, ""); got != "abcde" {
+		t.Fatalf("unexpected order: %s", got)
+	}
+}
+
+// TestRunnerRemoveAt test remove a task by index before start
+func TestRunnerRemoveAt(t *testing.T) {
+	p := New(WithSilent())
+	p.Add(func() error { return errors.New("a") },
+		func() error { return errors.New("b") },
+		func() error { return errors.New("c") })
+
+	if p.RemoveAt(3) || p.RemoveAt(-1) {
+		t.Fatal("expected out of range RemoveAt to return false")
+	}
+
+	if !p.RemoveAt(1) {
+		t.Fatal("expected RemoveAt(1) to return true")
+	}
+
+	_ = p.Start()
+	errs := p.GetAllErrors()
+	if len(errs) != 2 || errs[0].Error() != "a" || errs[1].Error() != "c" {
+		t.Fatalf("unexpected errors after remove: %v", errs)
+	}
+
+	p.Clear()
+	if err := p.Start(); err != nil || len(p.GetAllErrors()) != 0 {
+		t.Fatalf("expected no errors after clear, got: %v %v", err, p.GetAllErrors())
+	}
+}
+
+// TestRunnerLenPending test Len and Pending before, during and after a run
+func TestRunnerLenPending(t *testing.T) {
+	p := New(WithSilent())
+	release := make(chan struct{})
+	started := make(chan struct{})
+	p.Add(func() error {
+		close(started)
+		<-release
+		return nil
+	}, func() error { return nil }, func() error { return nil })
+
+	if p.Len() != 3 || p.Pending() != 3 {
+		t.Fatalf("unexpected len %d pending %d before start", p.Len(), p.Pending())
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- p.Start() }()
+	<-started
+	if n := p.Pending(); n != 2 {
+		t.Fatalf("unexpected pending during run: %d", n)
+	}
+
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if p.Pending() != 0 {
+		t.Fatalf("unexpected pending after run: %d", p.Pending())
+	}
+}
+
+// TestRunnerFinalizer test the finalizer receives the Start error and its panic is recovered
+func TestRunnerFinalizer(t *testing.T) {
+	var got []error
+	p := New(WithSilent(), WithTimeout(20*time.Millisecond), WithFinalizer(func(err error) {
+		got = append(got, err)
+		panic("finalizer panic")
+	}))
+	p.Add(func() error {
+		time.Sleep(100 * time.Millisecond)
+		return nil
+	})
+
+	if err := p.Start(); !errors.Is(err, ErrTimeout) {
+		t.Fatalf("expected timeout, got: %v", err)
+	}
+
+	p.Reset()
+	p.Add(func() error { return nil })
+	if err := p.Start(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(got) != 2 || !errors.Is(got[0], ErrTimeout) || got[1] != nil {
+		t.Fatalf("unexpected finalizer errors: %v", got)
+	}
+}
+
+// TestRunnerSortedErrors test errors sorted by task index
+func TestRunnerSortedErrors(t *testing.T) {
+	p := New(WithSilent())
+	for i := 0; i < 10; i++ {
+		i := i
+		p.Add(func() error {
+			if i%3 == 0 {
+				return nil
+			}
+
+			return fmt.Errorf("task %d", i)
+		})
+	}
+
+	_ = p.Start()
+	errs := p.SortedErrors()
+	want := []int{1, 2, 4, 5, 7, 8}
+	if len(errs) != len(want) {
+		t.Fatalf("unexpected sorted errors: %v", errs)
+	}
+
+	for i, e := range errs {
+		if e.ID != want[i] || e.Err.Error() != fmt.Sprintf("task %d", want[i]) {
+			t.Fatalf("unexpected sorted error at %d: %+v", i, e)
+		}
+	}
+}
+
+// TestRunnerOnInterrupt test the interrupt hook is called with the signal before the task ends
+func TestRunnerOnInterrupt(t *testing.T) {
+	notified := make(chan os.Signal, 1)
+	p := New(WithSilent(), WithNoSignals(), WithOnInterrupt(func(sig os.Signal) {
+		notified <- sig
+		panic("hook panic")
+	}))
+
+	var got os.Signal
+	p.Add(func() error {
+		p.Stop()
+		// 回调函数在当前任务结束之前就会被调用
+		select {
+		case got = <-notified:
+		case <-time.After(time.Second):
+		}
+
+		return nil
+	}, func() error { return nil })
+
+	if err := p.Start(); !errors.Is(err, ErrInterrupt) {
+		t.Fatalf("expected ErrInterrupt, got: %v", err)
+	}
+
+	if got == nil || got.String() != "runner stop" {
+		t.Fatalf("expected stop signal in hook, got: %v", got)
+	}
+}
+
+// TestRunnerDeadline test an absolute deadline and the last timeout option wins
+func TestRunnerDeadline(t *testing.T) {
+	executed := false
+	p := New(WithSilent(), WithDeadline(time.Now().Add(-time.Second)))
+	p.Add(func() error {
+		executed = true
+		return nil
+	})
+
+	if err := p.Start(); !errors.Is(err, ErrTimeout) || executed {
+		t.Fatalf("expected ErrTimeout without running tasks, got: %v %v", err, executed)
+	}
+
+	// 后设置的option生效
+	p = New(WithSilent(), WithDeadline(time.Now().Add(-time.Second)), WithTimeout(time.Second))
+	p.Add(func() error { return nil })
+	if err := p.Start(); err != nil {
+		t.Fatalf("expected timeout to override deadline, got: %v", err)
+	}
+
+	p = New(WithSilent(), WithTimeout(time.Hour), WithDeadline(time.Now().Add(20*time.Millisecond)))
+	p.Add(func() error {
+		time.Sleep(200 * time.Millisecond)
+		return nil
+	})
+
+	start := time.Now()
+	if err := p.Start(); !errors.Is(err, ErrTimeout) || time.Since(start) > 150*time.Millisecond {
+		t.Fatalf("expected deadline timeout, got: %v", err)
+	}
+}
+
+// TestRunnerStartFrom test resume from a checkpoint and treat earlier tasks as succeeded
+func TestRunnerStartFrom(t *testing.T) {
+	var executed, checkpoints []int
+	p := New(WithSilent(), WithNoSignals(), WithCheckpoint(func(id int) {
+		checkpoints = append(checkpoints, id)
+	}))
+	for i := 0; i < 5; i++ {
+		id := i
+		p.Add(func() error {
+			executed = append(executed, id)
+			if id == 2 {
+				p.Stop()
+				time.Sleep(20 * time.Millisecond)
+			}
+
+			return nil
+		})
+	}
+
+	if err := p.Start(); !errors.Is(err, ErrInterrupt) {
+		t.Fatalf("expected ErrInterrupt, got: %v", err)
+	}
+
+	from := p.GetInterruptLastTaskId()
+	if from != 3 || fmt.Sprint(checkpoints) != "[0 1 2]" {
+		t.Fatalf("unexpected interrupt id %d checkpoints %v", from, checkpoints)
+	}
+
+	executed = nil
+	if err := p.StartFrom(from); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if fmt.Sprint(executed) != "[3 4]" || p.GetLastTaskId() != 4 || p.Pending() != 0 {
+		t.Fatalf("unexpected resumed tasks: %v", executed)
+	}
+
+	// 之前的任务视为已经执行成功
+	executed = nil
+	p = New(WithSilent())
+	p.AddDependent("a", nil, func() error { return errors.New("a failed") })
+	p.AddDependent("b", []string{"a"}, func() error {
+		executed = append(executed, 1)
+		return nil
+	})
+
+	if err := p.StartFrom(1); err != nil || len(executed) != 1 {
+		t.Fatalf("expected dependent task executed, got: %v %v", err, executed)
+	}
+}
+
+// TestRunnerCancelOnError test the first error cancels the ctx of other concurrent tasks
+func TestRunnerCancelOnError(t *testing.T) {
+	errFirst := errors.New("first failed")
+	p := New(WithSilent(), WithConcurrency(3), WithCancelOnError())
+	canceled := make(chan struct{}, 2)
+	for i := 0; i < 2; i++ {
+		p.AddCtx(func(ctx context.Context) error {
+			select {
+			case <-ctx.Done():
+				canceled <- struct{}{}
+				return ctx.Err()
+			case <-time.After(time.Second):
+				return nil
+			}
+		})
+	}
+
+	p.AddCtx(func(ctx context.Context) error {
+		time.Sleep(10 * time.Millisecond)
+		return errFirst
+	})
+	executed := false
+	p.Add(func() error {
+		executed = true
+		return nil
+	})
+
+	start := time.Now()
+	if err := p.Start(); err != errFirst {
+		t.Fatalf("expected first error, got: %v", err)
+	}
+
+	if len(canceled) != 2 || time.Since(start) > 500*time.Millisecond {
+		t.Fatalf("expected siblings canceled early, canceled: %d", len(canceled))
+	}
+
+	if executed {
+		t.Fatal("expected no tasks dispatched after the first error")
+	}
+}
+
+// TestRunnerPriority test tasks executed by priority and then by insertion order
+func TestRunnerPriority(t *testing.T) {
+	var order []string
+	record := func(s string) func() error {
+		return func() error {
+			order = append(order, s)
+			return nil
+		}
+	}
+
+	p := New(WithSilent())
+	p.Add(record("d"))
+	p.AddPriority(1, record("b"))
+	p.AddPriority(5, record("a"))
+	p.AddPriority(1, record("c"))
+	p.AddPriority(-1, func() error { return errors.New("e") })
+
+	_ = p.Start()
+	if got := strings.Join(order, ""); got != "abcd" {
+		t.Fatalf("unexpected order: %s", got)
+	}
+
+	if errs := p.GetAllErrors(); errs[4] == nil || errs[4].Error() != "e" {
+		t.Fatalf("expected errors indexed by execution order, got: %v", errs)
+	}
+}
+
+// TestRunnerNewWithCancel test the cancel func stops the run like an interrupt
+func TestRunnerNewWithCancel(t *testing.T) {
+	p, cancel := NewWithCancel(WithSilent(), WithNoSignals())
+	executed := 0
+	p.Add(func() error {
+		executed++
+		cancel()
+		cancel()
+		time.Sleep(20 * time.Millisecond)
+		return nil
+	}, func() error {
+		executed++
+		return nil
+	})
+
+	if err := p.Start(); !errors.Is(err, ErrInterrupt) || executed != 1 {
+		t.Fatalf("expected ErrInterrupt after one task, got: %v %d", err, executed)
+	}
+
+	// 取消之后的执行都会立即停止
+	if err := p.Start(); !errors.Is(err, ErrInterrupt) || executed != 1 {
+		t.Fatalf("expected canceled runner not to run again, got: %v %d", err, executed)
+	}
+}
+
+// TestRunnerPanicErrorValue test error valued panics are matched by errors.Is
+func TestRunnerPanicErrorValue(t *testing.T) {
+	errPanic := errors.New("panic with error")
+	p := New(WithSilent())
+	p.Add(func() error { panic(fmt.Errorf("wrapped: %w", errPanic)) })
+	p.Add(func() error { panic("plain string") })
+	_ = p.Start()
+
+	errs := p.GetAllErrors()
+	var pe *PanicError
+	if !errors.Is(errs[0], errPanic) || !errors.As(errs[0], &pe) {
+		t.Fatalf("expected error valued panic to match errors.Is, got: %v", errs[0])
+	}
+
+	if !errors.As(errs[1], &pe) || pe.Value != "plain string" || errors.Unwrap(pe) != nil {
+		t.Fatalf("expected string valued panic without wrapped error, got: %v", errs[1])
+	}
+
+	if !strings.HasPrefix(pe.Error(), "current task panic: plain string") {
+		t.Fatalf("unexpected panic message: %s", pe.Error())
+	}
+}
+
+// TestRunnerFatalError test a fatal error stops the run while other errors continue
+func TestRunnerFatalError(t *testing.T) {
+	errSoft := errors.New("validation failed")
+	errFatal := errors.New("auth failed")
+	p := New(WithSilent(), WithFatalError(func(err error) bool {
+		return errors.Is(err, errFatal)
+	}))
+
+	executed := 0
+	p.Add(func() error { return errSoft })
+	p.Add(func() error {
+		executed++
+		return fmt.Errorf("task 1: %w", errFatal)
+	})
+	p.Add(func() error {
+		executed++
+		return nil
+	})
+
+	if err := p.Start(); !errors.Is(err, errFatal) || errors.Is(err, errSoft) {
+		t.Fatalf("expected fatal error only, got: %v", err)
+	}
+
+	if executed != 1 || len(p.GetAllErrors()) != 2 {
+		t.Fatalf("expected stop after fatal error, executed: %d errors: %v", executed, p.GetAllErrors())
+	}
+}
+
+// TestRunnerFatalErrorPanic test a panic in the fatal error func is treated as non-fatal
+func TestRunnerFatalErrorPanic(t *testing.T) {
+	for _, concurrency := range []int{1, 2} {
+		p := New(WithSilent(), WithConcurrency(concurrency), WithFatalError(func(err error) bool {
+			panic("fatal func panic")
+		}))
+		p.Add(func() error { return errors.New("failed") }, func() error { return nil })
+
+		if err := p.Start(); err == nil || len(p.Results()) != 2 {
+			t.Fatalf("expected all tasks executed, got: %v", err)
+		}
+	}
+}
+
+// TestRunnerTimeoutGoroutineExit test worker goroutines exit after a timeout
+func TestRunnerTimeoutGoroutineExit(t *testing.T) {
+	base := runtime.NumGoroutine()
+	var executed atomic.Int32
+	// 超时发生在一批任务执行的中间，保证超时的时候有正在执行的任务
+	p := New(WithSilent(), WithNoSignals(), WithTimeout(50*time.Millisecond), WithConcurrency(4))
+	for i := 0; i < 100; i++ {
+		p.Add(func() error {
+			executed.Add(1)
+			time.Sleep(20 * time.Millisecond)
+			return nil
+		})
+	}
+
+	if err := p.Start(); !errors.Is(err, ErrTimeout) {
+		t.Fatalf("expected ErrTimeout, got: %v", err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > base && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if n := runtime.NumGoroutine(); n > base {
+		t.Fatalf("expected run goroutines exited after timeout, goroutines: %d base: %d", n, base)
+	}
+
+	n := executed.Load()
+	time.Sleep(50 * time.Millisecond)
+	if n == 100 || executed.Load() != n {
+		t.Fatalf("expected no tasks started after timeout, executed: %d -> %d", n, executed.Load())
+	}
+
+	if len(p.Results()) >= int(n) {
+		t.Fatalf("expected results frozen at timeout, got: %d", len(p.Results()))
+	}
+}
+
+// TestRunnerEmpty test Start returns immediately without goroutines when no tasks are added
+func TestRunnerEmpty(t *testing.T) {
+	base := runtime.NumGoroutine()
+	during := 0
+	p := New(WithSilent(), WithTimeout(time.Second), WithFinalizer(func(err error) {
+		during = runtime.NumGoroutine()
+	}))
+
+	for i := 0; i < 10; i++ {
+		if err := p.Start(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if during > base || runtime.NumGoroutine() > base {
+		t.Fatalf("unexpected goroutines: %d %d base: %d", during, runtime.NumGoroutine(), base)
+	}
+
+	if p.State() != StateDone || len(p.GetAllErrors()) != 0 {
+		t.Fatalf("unexpected state: %v", p.State())
+	}
+}
+
+// TestRunnerTimeoutReportNoBlock test the run goroutine finishing after a timeout never blocks on reporting
+func TestRunnerTimeoutReportNoBlock(t *testing.T) {
+	base := runtime.NumGoroutine()
+	progress := make(chan Progress) // 没有接收方
+	finished := make(chan struct{})
+	p := New(WithSilent(), WithNoSignals(), WithTimeout(20*time.Millisecond), WithProgress(progress))
+	p.Add(func() error {
+		defer close(finished)
+		time.Sleep(60 * time.Millisecond)
+		return errors.New("finished after timeout")
+	})
+
+	if err := p.Start(); !errors.Is(err, ErrRunTimeout) {
+		t.Fatalf("expected ErrRunTimeout, got: %v", err)
+	}
+
+	<-finished
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > base && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if n := runtime.NumGoroutine(); n > base {
+		t.Fatalf("expected run goroutine exited after reporting, goroutines: %d base: %d", n, base)
+	}
+
+	if errs := p.GetAllErrors(); len(errs) != 0 {
+		t.Fatalf("expected late result dropped, got: %v", errs)
+	}
+}
+
+// TestRunnerOnError test the error hook is called once after the last retry
+func TestRunnerOnError(t *testing.T) {
+	var failures []string
+	p := New(WithSilent(), WithRetry(3, time.Millisecond), WithOnError(func(id int, name string, err error) {
+		failures = append(failures, fmt.Sprintf("%d:%s:%v", id, name, err))
+		panic("on error hook panic")
+	}))
+	p.AddNamed("ok", func() error { return nil })
+	p.AddNamed("bad", func() error { return errors.New("failed") })
+	p.Add(func() error { return nil })
+
+	_ = p.Start()
+	if fmt.Sprint(failures) != "[1:bad:failed]" {
+		t.Fatalf("unexpected failures: %v", failures)
+	}
+}
+
+// TestRunnerIfPrev test tasks conditioned on the previous task result
+func TestRunnerIfPrev(t *testing.T) {
+	for _, concurrency := range []int{1, 4} {
+		var executed []string
+		var mu sync.Mutex
+		record := func(s string, err error) func() error {
+			return func() error {
+				if s == "build" {
+					time.Sleep(20 * time.Millisecond)
+				}
+
+				mu.Lock()
+				executed = append(executed, s)
+				mu.Unlock()
+				return err
+			}
+		}
+
+		p := New(WithSilent(), WithConcurrency(concurrency))
+		p.AddIfPrevSucceeded(record("first", nil))
+		p.Add(record("build", errors.New("build failed")))
+		p.AddIfPrevSucceeded(record("deploy", nil))
+		p.Add(record("test", errors.New("test failed")))
+		p.AddIfPrevFailed(record("rollback", nil))
+		p.AddIfPrevFailed(record("alert", nil))
+
+		_ = p.Start()
+		if fmt.Sprint(executed) != "[build test rollback]" {
+			t.Fatalf("unexpected executed tasks with concurrency %d: %v", concurrency, executed)
+		}
+
+		reasons := p.GetSkipReasons()
+		if reasons[0] != "no previous task" || reasons[2] != "previous task failed" || reasons[5] != "previous task succeeded" {
+			t.Fatalf("unexpected skip reasons: %v", reasons)
+		}
+	}
+}
+
+// TestRunnerFlattenedErrors test joined task errors flattened in task order
+func TestRunnerFlattenedErrors(t *testing.T) {
+	errA, errB, errC := errors.New("a"), errors.New("b"), errors.New("c")
+	wrapped := fmt.Errorf("wrapped: %w", errC)
+	p := New(WithSilent())
+	p.Add(func() error { return errors.Join(errA, errors.Join(errB, nil)) })
+	p.Add(func() error { return nil })
+	p.Add(func() error { return wrapped })
+
+	_ = p.Start()
+	errs := p.FlattenedErrors()
+	if len(errs) != 3 || errs[0] != errA || errs[1] != errB || errs[2] != wrapped {
+		t.Fatalf("unexpected flattened errors: %v", errs)
+	}
+}
+
+// TestRunnerTimeoutWarning test the warning callback before the run timeout
+func TestRunnerTimeoutWarning(t *testing.T) {
+	warned := make(chan time.Duration, 1)
+	p := New(WithSilent(), WithTimeout(time.Second), WithTimeoutWarning(20*time.Millisecond, func(elapsed time.Duration) {
+		warned <- elapsed
+	}))
+	p.Add(func() error {
+		time.Sleep(60 * time.Millisecond)
+		return nil
+	})
+
+	if err := p.Start(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	select {
+	case elapsed := <-warned:
+		if elapsed < 20*time.Millisecond {
+			t.Fatalf("unexpected elapsed: %v", elapsed)
+		}
+	default:
+		t.Fatal("expected timeout warning")
+	}
+
+	// 执行在at之前结束时不会告警
+	p = New(WithSilent(), WithTimeoutWarning(20*time.Millisecond, func(elapsed time.Duration) {
+		warned <- elapsed
+	}))
+	p.Add(func() error { return nil })
+	_ = p.Start()
+	time.Sleep(40 * time.Millisecond)
+	if len(warned) != 0 {
+		t.Fatal("expected no warning after the run finished")
+	}
+}
+
+// TestRunnerSucceededFailed test succeeded and failed counters during a concurrent run
+func TestRunnerSucceededFailed(t *testing.T) {
+	p := New(WithSilent(), WithConcurrency(4))
+	for i := 0; i < 20; i++ {
+		i := i
+		p.Add(func() error {
+			if i%4 == 0 {
+				return errors.New("failed")
+			}
+
+			return nil
+		})
+	}
+
+	done := p.StartChan()
+	for finished := false; !finished; {
+		select {
+		case <-done:
+			finished = true
+		default:
+			_ = p.Succeeded() + p.Failed()
+		}
+	}
+
+	if p.Succeeded() != 15 || p.Failed() != 5 {
+		t.Fatalf("unexpected counters: %d %d", p.Succeeded(), p.Failed())
+	}
+
+	p.Clear()
+	_ = p.Start()
+	if p.Succeeded() != 0 || p.Failed() != 0 {
+		t.Fatalf("expected counters reset, got: %d %d", p.Succeeded(), p.Failed())
+	}
+}
+
+// TestRunnerGetPanics test panics recorded separately from errors
+func TestRunnerGetPanics(t *testing.T) {
+	p := New(WithSilent())
+	p.Add(func() error { return errors.New("expected") })
+	p.Add(func() error { panic("bug") })
+	p.Add(func() error { return nil })
+
+	_ = p.Start()
+	panics := p.GetPanics()
+	if len(panics) != 1 || panics[1].Value != "bug" || len(panics[1].Stack) == 0 {
+		t.Fatalf("unexpected panics: %v", panics)
+	}
+
+	if len(p.GetAllErrors()) != 2 {
+		t.Fatalf("expected panic still recorded as error, got: %v", p.GetAllErrors())
+	}
+}
+
+// TestRunnerSleepCtx test sleep returns early when ctx is canceled
+func TestRunnerSleepCtx(t *testing.T) {
+	p := New(WithSilent())
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+
+	start := time.Now()
+	if p.sleepCtx(ctx, time.Hour) || time.Since(start) > time.Second {
+		t.Fatal("expected sleep canceled promptly")
+	}
+
+	if !p.sleepCtx(context.Background(), time.Millisecond) {
+		t.Fatal("expected sleep completed")
+	}
+
+	// 重试退避期间取消ctx，Start立即返回
+	p = New(WithSilent(), WithNoSignals(), WithRetry(3, time.Hour))
+	p.Add(func() error { return errors.New("temporary error") })
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+
+	start = time.Now()
+	if err := p.StartContext(ctx); err == nil || time.Since(start) > time.Second {
+		t.Fatalf("expected canceled during backoff, got: %v after %v", err, time.Since(start))
+	}
+}
+
+// TestRunnerETA test ETA and Elapsed estimated from completed tasks
+func TestRunnerETA(t *testing.T) {
+	p := New(WithSilent())
+	if p.ETA() != 0 || p.Elapsed() != 0 {
+		t.Fatal("expected zero eta and elapsed before start")
+	}
+
+	check := make(chan struct{})
+	resume := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		i := i
+		p.Add(func() error {
+			time.Sleep(20 * time.Millisecond)
+			if i == 1 {
+				close(check)
+				<-resume
+			}
+
+			return nil
+		})
+	}
+
+	done := p.StartChan()
+	<-check
+	// 第一个任务完成之后，剩余2个任务没有开始执行，估算剩余时间大约为40ms
+	if eta := p.ETA(); eta < 30*time.Millisecond || eta > time.Second {
+		t.Fatalf("unexpected eta: %v", eta)
+	}
+
+	close(resume)
+	<-done
+	if p.ETA() != 0 || p.Elapsed() < 80*time.Millisecond {
+		t.Fatalf("unexpected eta %v elapsed %v after run", p.ETA(), p.Elapsed())
+	}
+}
+
+// TestRunnerBaseContext test values and cancellation of the base context propagate to tasks
+func TestRunnerBaseContext(t *testing.T) {
+	type tenantKey struct{}
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), tenantKey{}, "t1"))
+	p := New(WithSilent(), WithBaseContext(ctx), WithTimeout(time.Second))
+
+	var tenant interface{}
+	p.AddCtx(func(ctx context.Context) error {
+		tenant = ctx.Value(tenantKey{})
+		cancel()
+		return nil
+	})
+	p.Add(func() error { return nil })
+
+	if err := p.Start(); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got: %v", err)
+	}
+
+	if tenant != "t1" || p.GetLastTaskId() != 0 {
+		t.Fatalf("unexpected tenant %v or last task %d", tenant, p.GetLastTaskId())
+	}
+}
+
+// TestRunnerStartMatching test only tasks with matched tags are executed
+func TestRunnerStartMatching(t *testing.T) {
+	p := New(WithSilent())
+	executed := make([]int, 0)
+	p.AddTagged([]string{"db"}, func() error {
+		executed = append(executed, 0)
+		return nil
+	})
+	p.AddTagged([]string{"external", "cache"}, func() error {
+		executed = append(executed, 1)
+		return nil
+	})
+	p.Add(func() error {
+		executed = append(executed, 2)
+		return nil
+	})
+
+	external := func(tags []string) bool {
+		for _, tag := range tags {
+			if tag == "external" {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	if err := p.StartMatching(external); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(executed) != 1 || executed[0] != 1 {
+		t.Fatalf("unexpected executed tasks: %v", executed)
+	}
+
+	if reasons := p.GetSkipReasons(); len(reasons) != 2 || reasons[0] != "tags not matched" || reasons[2] != "tags not matched" {
+		t.Fatalf("unexpected skip reasons: %v", reasons)
+	}
+
+	// selector只对本次执行生效
+	executed = executed[:0]
+	if err := p.Start(); err != nil || len(executed) != 3 {
+		t.Fatalf("unexpected result: %v %v", err, executed)
+	}
+}
+
+// TestRunnerRequireTasks test empty runner with and without WithRequireTasks
+func TestRunnerRequireTasks(t *testing.T) {
+	p := New(WithSilent(), WithRequireTasks())
+	if err := p.Start(); !errors.Is(err, ErrNoTasks) {
+		t.Fatalf("expected ErrNoTasks, got: %v", err)
+	}
+
+	if err := p.Validate(); !errors.Is(err, ErrNoTasks) {
+		t.Fatalf("expected ErrNoTasks from validate, got: %v", err)
+	}
+
+	p.Add(func() error { return nil })
+	if err := p.Start(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	p = New(WithSilent())
+	if err := p.Start(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// TestRunnerGetAllErrorsCopy test GetAllErrors returns a copy safe to read while tasks are writing
+func TestRunnerGetAllErrorsCopy(t *testing.T) {
+	p := New(WithSilent(), WithConcurrency(4))
+	for i := 0; i < 200; i++ {
+		p.Add(func() error { return errors.New("failed") })
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for p.State() != StateDone {
+			for k := range p.GetAllErrors() {
+				_ = k
+			}
+		}
+	}()
+
+	_ = p.Start()
+	<-done
+
+	errs := p.GetAllErrors()
+	delete(errs, 0)
+	if len(p.GetAllErrors()) != 200 {
+		t.Fatalf("expected internal errors not modified, got: %d", len(p.GetAllErrors()))
+	}
+}
+
+// TestRunnerRemainingTasks test tasks never finished after a timeout
+func TestRunnerRemainingTasks(t *testing.T) {
+	p := New(WithSilent(), WithTimeout(30*time.Millisecond))
+	p.Add(func() error { return nil })
+	p.AddConditional(func() bool { return false }, func() error { return nil })
+	p.Add(func() error {
+		time.Sleep(100 * time.Millisecond)
+		return nil
+	})
+	p.Add(func() error { return nil })
+
+	if got := p.RemainingTasks(); len(got) != 4 {
+		t.Fatalf("expected all tasks remaining before start, got: %v", got)
+	}
+
+	if err := p.Start(); !errors.Is(err, ErrRunTimeout) {
+		t.Fatalf("expected ErrRunTimeout, got: %v", err)
+	}
+
+	if got := p.RemainingTasks(); !reflect.DeepEqual(got, []int{2, 3}) {
+		t.Fatalf("unexpected remaining tasks: %v", got)
+	}
+}
+
+// TestRunnerRunOne test a single task executed by index
+func TestRunnerRunOne(t *testing.T) {
+	errTask := errors.New("task failed")
+	executed := make([]int, 0)
+	attempts := 0
+	p := New(WithSilent(), WithRetry(2, time.Millisecond))
+	p.Add(func() error {
+		executed = append(executed, 0)
+		return nil
+	})
+	p.AddIfPrevSucceeded(func() error {
+		executed = append(executed, 1)
+		attempts++
+		return errTask
+	})
+	p.Add(func() error {
+		executed = append(executed, 2)
+		return nil
+	})
+
+	if err := p.RunOne(1); !errors.Is(err, errTask) {
+		t.Fatalf("expected task error, got: %v", err)
+	}
+
+	if !reflect.DeepEqual(executed, []int{1, 1}) || attempts != 2 {
+		t.Fatalf("unexpected executed tasks: %v", executed)
+	}
+
+	if errs := p.GetAllErrors(); len(errs) != 1 || errs[1] != errTask || p.GetLastTaskId() != 1 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+
+	for _, index := range []int{-1, 3} {
+		if err := p.RunOne(index); !errors.Is(err, ErrNoSuchTask) {
+			t.Fatalf("expected ErrNoSuchTask, got: %v", err)
+		}
+	}
+}
+
+// TestRunnerRunOneAfterDependencies test RunOne after a Start with dependencies
+func TestRunnerRunOneAfterDependencies(t *testing.T) {
+	p := New(WithSilent())
+	p.AddDependent("a", nil, func() error { return nil })
+	p.AddDependent("b", []string{"a"}, func() error { return nil })
+
+	if err := p.Start(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := p.RunOne(0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if results := p.GetResults(); len(results) != 1 {
+		t.Fatalf("unexpected results: %v", results)
+	}
+}
+
+// TestRunnerQueueSize test Add blocks while the queue is full during a run
+func TestRunnerQueueSize(t *testing.T) {
+	p := New(WithSilent(), WithNoSignals(), WithConcurrency(2), WithQueueSize(2))
+	release := make(chan struct{})
+	started := make(chan struct{}, 2)
+	for i := 0; i < 2; i++ {
+		p.Add(func() error {
+			started <- struct{}{}
+			<-release
+			return nil
+		})
+	}
+
+	_ = p.StartAsync()
+	<-started
+	<-started
+
+	p.Add(func() error { return nil }, func() error { return nil })
+	added := make(chan struct{})
+	go func() {
+		defer close(added)
+		p.Add(func() error { return nil })
+	}()
+
+	select {
+	case <-added:
+		t.Fatal("expected Add blocked while the queue is full")
+	case <-time.After(30 * time.Millisecond):
+	}
+
+	close(release)
+	if err := p.Wait(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	select {
+	case <-added:
+	case <-time.After(time.Second):
+		t.Fatal("expected Add unblocked after the run")
+	}
+}
+
+// TestRunnerQueueSizeStream test the stream producer is throttled by the workers
+func TestRunnerQueueSizeStream(t *testing.T) {
+	p := New(WithSilent(), WithNoSignals(), WithConcurrency(2), WithQueueSize(1))
+	var mu sync.Mutex
+	running, maxRunning, executed := 0, 0, 0
+
+	tasks := make(chan func() error)
+	go func() {
+		defer close(tasks)
+		for i := 0; i < 10; i++ {
+			tasks <- func() error {
+				mu.Lock()
+				running++
+				if running > maxRunning {
+					maxRunning = running
+				}
+				mu.Unlock()
+
+				time.Sleep(5 * time.Millisecond)
+
+				mu.Lock()
+				running--
+				executed++
+				mu.Unlock()
+				return nil
+			}
+		}
+	}()
+
+	if err := p.StartStream(context.Background(), tasks); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if executed != 10 || maxRunning > 2 {
+		t.Fatalf("unexpected executed %d or max running %d", executed, maxRunning)
+	}
+}
+
+// TestRunnerOutcome test TimedOut and Interrupted reflect the last run
+func TestRunnerOutcome(t *testing.T) {
+	p := New(WithSilent(), WithNoSignals(), WithTimeout(20*time.Millisecond), WithTaskTimeout(5*time.Millisecond))
+	p.AddCtx(func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	_ = p.Start()
+	if p.TimedOut() || p.Interrupted() {
+		t.Fatalf("unexpected outcome after task timeout: %v %v", p.TimedOut(), p.Interrupted())
+	}
+
+	p = New(WithSilent(), WithNoSignals(), WithTimeout(20*time.Millisecond))
+	p.Add(func() error {
+		time.Sleep(100 * time.Millisecond)
+		return nil
+	})
+	_ = p.StartAsync()
+	_ = p.Wait()
+	if !p.TimedOut() || p.Interrupted() {
+		t.Fatalf("expected timed out, got: %v %v", p.TimedOut(), p.Interrupted())
+	}
+
+	p = New(WithSilent(), WithNoSignals())
+	p.Add(func() error {
+		p.Stop()
+		time.Sleep(20 * time.Millisecond)
+		return nil
+	}, func() error { return nil })
+	_ = p.StartAsync()
+	_ = p.Wait()
+	if p.TimedOut() || !p.Interrupted() {
+		t.Fatalf("expected interrupted, got: %v %v", p.TimedOut(), p.Interrupted())
+	}
+}
+
+// TestRunnerSlowTask test slow tasks are logged and reported
+func TestRunnerSlowTask(t *testing.T) {
+	l := &recordLogger{}
+	var slow []string
+	p := New(WithLogger(l), WithLogLevel(LevelWarn), WithSlowTaskThreshold(20*time.Millisecond),
+		WithOnSlowTask(func(id int, name string, d time.Duration) {
+			slow = append(slow, fmt.Sprintf("%d:%s", id, name))
+			panic("slow hook panic")
+		}))
+	p.Add(func() error { return nil })
+	p.AddNamed("slow", func() error {
+		time.Sleep(30 * time.Millisecond)
+		return nil
+	})
+
+	if err := p.Start(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(slow) != 1 || slow[0] != "1:slow" {
+		t.Fatalf("unexpected slow tasks: %v", slow)
+	}
+
+	if len(l.lines) != 2 || !strings.Contains(l.lines[0], "slow task") || !strings.Contains(l.lines[1], "throw panic") {
+		t.Fatalf("unexpected logs: %q", l.lines)
+	}
+}
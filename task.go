@@ -0,0 +1,41 @@
+package runner
+
+import (
+	"context"
+	"time"
+)
+
+// Task 结构化的任务定义，新增的任务属性都会以字段的形式添加，不需要再增加AddXxx方法
+type Task struct {
+	Name     string                          // 任务名称，为空时使用任务index作为名称
+	Tags     []string                        // 任务标签，用于StartMatching筛选需要执行的任务
+	Priority int                             // 任务优先级，Start时优先级高的任务先执行，和AddPriority相同
+	Timeout  time.Duration                   // 单个任务的超时时间，为0时使用WithTaskTimeout设置的超时时间
+	Fn       func(ctx context.Context) error // 任务执行的func，为nil时执行到该任务会记录ErrNilTask
+}
+
+// AddTask 将结构化定义的任务添加到r.tasks队列中，Add等方法都可以看作是AddTask的简化形式
+// 任务超时时记录ErrTaskTimeout，和WithTaskTimeout一样，超时之后不感知ctx的任务会继续在后台运行
+func (r *Runner) AddTask(t Task) {
+	r.addTasks(t.task())
+}
+
+// task 将Task转换为内部的任务
+func (t Task) task() task {
+	return task{
+		name:    t.Name,
+		tags:    append([]string(nil), t.Tags...),
+		prio:    t.Priority,
+		timeout: t.Timeout,
+		fn:      wrapCtxTask(t.Fn),
+	}
+}
+
+// timeoutOf 获取任务t每一次执行的超时时间，任务没有设置超时时间时使用r.taskTimeout
+func (r *Runner) timeoutOf(t task) time.Duration {
+	if t.timeout > 0 {
+		return t.timeout
+	}
+
+	return r.taskTimeout
+}
@@ -0,0 +1,50 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestRunnerAddTask test structured task definitions
+func TestRunnerAddTask(t *testing.T) {
+	order := make([]string, 0)
+	p := New(WithSilent())
+	p.AddTask(Task{Name: "low", Fn: func(ctx context.Context) error {
+		order = append(order, "low")
+		return nil
+	}})
+	p.AddTask(Task{Name: "slow", Priority: 1, Timeout: 20 * time.Millisecond, Fn: func(ctx context.Context) error {
+		order = append(order, "slow")
+		<-ctx.Done()
+		return ctx.Err()
+	}})
+	p.AddTask(Task{Name: "tagged", Tags: []string{"external"}, Fn: func(ctx context.Context) error {
+		order = append(order, "tagged")
+		return nil
+	}})
+	p.AddTask(Task{Name: "nil"})
+
+	if err := p.Start(); !errors.Is(err, ErrTaskTimeout) || !errors.Is(err, ErrNilTask) {
+		t.Fatalf("expected task timeout and nil task, got: %v", err)
+	}
+
+	if len(order) != 3 || order[0] != "slow" || order[1] != "low" {
+		t.Fatalf("unexpected order: %v", order)
+	}
+
+	errs := p.GetNamedErrors()
+	if errs["slow"] != ErrTaskTimeout || errs["low"] != nil {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+
+	order = order[:0]
+	if err := p.StartMatching(func(tags []string) bool { return len(tags) > 0 }); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(order) != 1 || order[0] != "tagged" {
+		t.Fatalf("unexpected order: %v", order)
+	}
+}
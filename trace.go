@@ -0,0 +1,60 @@
+package runner
+
+import (
+	"context"
+)
+
+// Tracer 任务执行的链路追踪接口，可以基于OpenTelemetry等实现
+// runner本身不依赖任何链路追踪库
+type Tracer interface {
+	// Start 开启一个名称为name的span，返回的ctx中携带了该span，会传递给可以感知ctx的任务
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span 一个任务执行对应的span
+type Span interface {
+	// RecordError 记录任务返回的错误或者panic
+	RecordError(err error)
+	// End 结束该span
+	End()
+}
+
+// WithTracer 设置链路追踪的实现，每个任务都会在独立的span中执行，span名称为任务名称或者任务index
+func WithTracer(t Tracer) Option {
+	return func(r *Runner) {
+		r.tracer = t
+	}
+}
+
+// startSpan 为任务id为k的任务t开启span，没有设置tracer时返回的span为nil
+// tracer中的panic会被捕获并记录日志，此时任务不在span中执行
+func (r *Runner) startSpan(ctx context.Context, k int, t task) (context.Context, Span) {
+	if r.tracer == nil {
+		return ctx, nil
+	}
+
+	spanCtx, span := ctx, Span(nil)
+	r.safeCall("tracer", func() {
+		c, s := r.tracer.Start(ctx, r.nameOf(k, t))
+		if c != nil {
+			spanCtx, span = c, s
+		}
+	})
+
+	return spanCtx, span
+}
+
+// endSpan 记录任务的错误并结束span，span中的panic会被捕获并记录日志
+func (r *Runner) endSpan(span Span, err error) {
+	if span == nil {
+		return
+	}
+
+	r.safeCall("tracer", func() {
+		if err != nil {
+			span.RecordError(err)
+		}
+
+		span.End()
+	})
+}
@@ -0,0 +1,94 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type spanKey struct{}
+
+// recordSpan 记录错误以及是否结束的span
+type recordSpan struct {
+	name  string
+	err   error
+	ended bool
+}
+
+func (s *recordSpan) RecordError(err error) { s.err = err }
+
+func (s *recordSpan) End() { s.ended = true }
+
+// recordTracer 记录所有开启的span
+type recordTracer struct {
+	spans []*recordSpan
+}
+
+func (t *recordTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	span := &recordSpan{name: name}
+	t.spans = append(t.spans, span)
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// TestRunnerTracer test run every task in its own span
+func TestRunnerTracer(t *testing.T) {
+	tracer := &recordTracer{}
+	p := New(WithTracer(tracer))
+	p.AddNamed("first", func() error { return nil })
+	p.AddCtx(func(ctx context.Context) error {
+		if _, ok := ctx.Value(spanKey{}).(*recordSpan); !ok {
+			return errors.New("span not found in context")
+		}
+
+		panic("boom")
+	})
+	_ = p.Start()
+
+	if len(tracer.spans) != 2 {
+		t.Fatalf("expected 2 spans, got: %d", len(tracer.spans))
+	}
+
+	first, second := tracer.spans[0], tracer.spans[1]
+	if first.name != "first" || first.err != nil || !first.ended {
+		t.Fatalf("unexpected first span: %+v", first)
+	}
+
+	var pe *PanicError
+	if second.name != "1" || !errors.As(second.err, &pe) || !second.ended {
+		t.Fatalf("unexpected second span: %+v", second)
+	}
+}
+
+// panicTracer Start或者span的End会panic的tracer
+type panicTracer struct {
+	panicStart bool
+}
+
+func (t panicTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	if t.panicStart {
+		panic("start panic")
+	}
+
+	return ctx, panicSpan{}
+}
+
+// panicSpan End会panic的span
+type panicSpan struct{}
+
+func (panicSpan) RecordError(err error) {}
+
+func (panicSpan) End() { panic("end panic") }
+
+// TestRunnerTracerPanic test tracer panics do not affect the tasks
+func TestRunnerTracerPanic(t *testing.T) {
+	for _, concurrency := range []int{1, 2} {
+		for _, tracer := range []panicTracer{{panicStart: true}, {}} {
+			p := New(WithSilent(), WithConcurrency(concurrency), WithTracer(tracer))
+			p.Add(func() error { return nil }, func() error { return nil })
+
+			if err := p.Start(); err != nil || len(p.Results()) != 2 {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package runner
+
+import "context"
+
+// TypedRunner 所有任务都返回相同类型结果的runner，基于Runner相同的执行流程，获取结果时不需要类型断言
+// 除了Add和Results之外，其他方法以及Option都和Runner相同，任务返回值类型不同时使用Runner的AddResult
+type TypedRunner[T any] struct {
+	*Runner
+}
+
+// NewTyped 创建一个所有任务都返回T类型结果的runner
+func NewTyped[T any](opts ...Option) *TypedRunner[T] {
+	return &TypedRunner[T]{Runner: New(opts...)}
+}
+
+// Add 将返回T类型结果的任务添加到任务队列中，和Runner.Add一样可以在任务执行的过程中并发调用
+func (r *TypedRunner[T]) Add(tasks ...func() (T, error)) {
+	ts := make([]task, 0, len(tasks))
+	for _, fn := range tasks {
+		ts = append(ts, task{fn: wrapTypedTask(fn)})
+	}
+
+	r.addTasks(ts...)
+}
+
+// Results 获取已经执行成功的任务的返回值，key为任务index，返回值为nil时为T的零值，出错的任务以及返回值不是T类型的任务不会返回
+func (r *TypedRunner[T]) Results() map[int]T {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	values := make(map[int]T, len(r.results))
+	for k, res := range r.results {
+		if res.Err != nil {
+			continue
+		}
+
+		// 返回nil的任务，例如TypedRunner[error]，使用T的零值
+		if res.Value == nil {
+			var zero T
+			values[k] = zero
+			continue
+		}
+
+		if v, ok := res.Value.(T); ok {
+			values[k] = v
+		}
+	}
+
+	return values
+}
+
+// wrapTypedTask 将func() (T, error)适配为任务执行的func，忽略ctx，fn为nil时返回nil
+func wrapTypedTask[T any](fn func() (T, error)) func(ctx context.Context) (interface{}, error) {
+	if fn == nil {
+		return nil
+	}
+
+	return func(ctx context.Context) (interface{}, error) {
+		return fn()
+	}
+}
@@ -0,0 +1,45 @@
+package runner
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestTypedRunner test typed results without type assertions
+func TestTypedRunner(t *testing.T) {
+	errTask := errors.New("task failed")
+	p := NewTyped[int](WithSilent(), WithRetry(2, time.Millisecond))
+	p.Add(func() (int, error) { return 1, nil })
+	p.Add(func() (int, error) { return 0, errTask })
+	p.Add(func() (int, error) { panic("boom") }, func() (int, error) { return 3, nil })
+
+	err := p.Start()
+	var pe *PanicError
+	if !errors.Is(err, errTask) || !errors.As(err, &pe) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	results := p.Results()
+	if len(results) != 2 || results[0] != 1 || results[3] != 3 {
+		t.Fatalf("unexpected results: %v", results)
+	}
+
+	if attempts := p.GetAttempts(); attempts[1] != 2 {
+		t.Fatalf("expected retried task, got: %v", attempts)
+	}
+}
+
+// TestTypedRunnerNilValue test nil values are returned as the zero value of T
+func TestTypedRunnerNilValue(t *testing.T) {
+	p := NewTyped[error](WithSilent())
+	p.Add(func() (error, error) { return nil, nil })
+
+	if err := p.Start(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if results := p.Results(); len(results) != 1 || results[0] != nil {
+		t.Fatalf("unexpected results: %v", results)
+	}
+}
@@ -0,0 +1,48 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+)
+
+// unsafePanic 通过AddUnsafe添加的任务或者WithPanicHandler的处理函数panic时的值，execTask以及执行任务的goroutine不会捕获，会继续panic
+type unsafePanic struct {
+	source string      // panic的来源，例如unsafe task
+	value  interface{} // 任务panic时的值
+}
+
+// Error 实现error接口，进程崩溃时输出panic的来源以及原始的panic值
+func (p unsafePanic) Error() string {
+	return fmt.Sprintf("%s panic: %v", p.source, p.value)
+}
+
+// AddUnsafe 将不捕获panic的任务添加到r.tasks队列中，任务panic时不会记录PanicError，而是直接导致进程崩溃
+// 适用于可信的任务，希望程序bug尽早暴露，该任务不受WithPanicPolicy以及WithPanicHandler影响，通过Add添加的任务依然会捕获panic
+// 注意：任务在Start之外的goroutine中执行，Start的调用方无法通过recover捕获该panic，崩溃时的堆栈包含任务panic的位置
+func (r *Runner) AddUnsafe(fn func() error) {
+	r.addTasks(task{fn: wrapUnsafeTask(fn), raw: fn})
+}
+
+// wrapUnsafeTask 将func() error适配为任务执行的func，任务panic时以unsafePanic继续panic，fn为nil时返回nil
+func wrapUnsafeTask(fn func() error) func(ctx context.Context) (interface{}, error) {
+	if fn == nil {
+		return nil
+	}
+
+	return func(ctx context.Context) (interface{}, error) {
+		defer func() {
+			if e := recover(); e != nil {
+				panic(unsafePanic{source: "unsafe task", value: e})
+			}
+		}()
+
+		return nil, fn()
+	}
+}
+
+// repanicUnsafe e为unsafePanic时继续panic，不进行捕获
+func repanicUnsafe(e interface{}) {
+	if p, ok := e.(unsafePanic); ok {
+		panic(p)
+	}
+}
@@ -0,0 +1,53 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// TestRunnerUnsafeTask test panics of unsafe tasks are not recovered by execTask
+func TestRunnerUnsafeTask(t *testing.T) {
+	p := New(WithSilent())
+	fn := wrapUnsafeTask(func() error { panic("boom") })
+
+	func() {
+		defer func() {
+			e := recover()
+			if up, ok := e.(unsafePanic); !ok || up.value != "boom" {
+				t.Fatalf("expected unsafe panic propagated, got: %v", e)
+			}
+		}()
+
+		_, _ = p.execTask(context.Background(), fn)
+	}()
+
+	// 通过Add添加的任务依然会捕获panic
+	p.Add(func() error { panic("recovered") })
+	var pe *PanicError
+	if err := p.Start(); !errors.As(err, &pe) {
+		t.Fatalf("expected PanicError, got: %v", err)
+	}
+}
+
+// TestRunnerUnsafeCrash test an unsafe task panic crashes the process
+func TestRunnerUnsafeCrash(t *testing.T) {
+	if os.Getenv("RUNNER_UNSAFE_CRASH") == "1" {
+		p := New(WithSilent())
+		p.AddUnsafe(func() error { panic("unsafe boom") })
+		_ = p.Start()
+		return
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestRunnerUnsafeCrash$")
+	cmd.Env = append(os.Environ(), "RUNNER_UNSAFE_CRASH=1")
+	out, err := cmd.CombinedOutput()
+
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || !strings.Contains(string(out), "unsafe task panic: unsafe boom") {
+		t.Fatalf("expected process crashed, got: %v\n%s", err, out)
+	}
+}
@@ -0,0 +1,77 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrNilTask task func is nil
+	ErrNilTask = errors.New("task func is nil")
+
+	// ErrDuplicateTaskName task name is duplicated
+	ErrDuplicateTaskName = errors.New("duplicate task name")
+)
+
+// WithDryRun 设置只校验任务队列并输出将要执行的任务，不会调用任务函数
+// Start会先调用Validate，校验失败时直接返回错误，校验通过后按照执行顺序输出每个任务的id和名称
+// 执行条件、before task等回调函数也不会被调用，适用于在CI中检查runner的配置
+func WithDryRun() Option {
+	return func(r *Runner) {
+		r.dryRun = true
+	}
+}
+
+// Validate 校验任务队列是否合法，不会执行任何任务
+// 检查任务函数是否为nil、任务名称是否重复以及依赖关系是否可以解析，返回所有问题合并之后的错误
+// 任务队列为空时只会输出一条警告日志，设置了WithRequireTasks时返回ErrNoTasks
+func (r *Runner) Validate() error {
+	r.mu.Lock()
+	tasks := make([]task, len(r.tasks))
+	copy(tasks, r.tasks)
+	r.mu.Unlock()
+
+	if len(tasks) == 0 {
+		r.log(LevelWarn, "runner has no tasks")
+	}
+
+	var errs []error
+	if len(tasks) == 0 && r.requireTasks {
+		errs = append(errs, ErrNoTasks)
+	}
+
+	names := make(map[string]int, len(tasks))
+	for k, t := range tasks {
+		if t.fn == nil {
+			errs = append(errs, fmt.Errorf("%w: %s", ErrNilTask, r.nameOf(k, t)))
+		}
+
+		if t.name == "" {
+			continue
+		}
+
+		if i, ok := names[t.name]; ok {
+			errs = append(errs, fmt.Errorf("%w: %s used by task %d and %d", ErrDuplicateTaskName, t.name, i, k))
+			continue
+		}
+
+		names[t.name] = k
+	}
+
+	if _, _, err := r.sortDependencies(tasks); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
+
+// dryRunTask 只输出任务信息，不执行任务，依赖该任务的任务会继续执行
+func (r *Runner) dryRunTask(gen uint64, k int, t task) {
+	fields := r.taskFields(k, t)
+	if len(t.deps) > 0 {
+		fields = append(fields, "deps", t.deps)
+	}
+
+	r.log(LevelInfo, "dry run task", fields...)
+	r.markDependency(gen, t, false)
+}
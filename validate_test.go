@@ -0,0 +1,107 @@
+package runner
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestRunnerValidate test report nil funcs, duplicate names and bad dependencies
+func TestRunnerValidate(t *testing.T) {
+	p := New(WithSilent())
+	p.AddNamed("a", func() error { return nil })
+	p.AddNamed("a", func() error { return nil })
+	p.Add(nil)
+	p.AddDependent("b", []string{"missing"}, func() error { return nil })
+
+	err := p.Validate()
+	for _, want := range []error{ErrNilTask, ErrDuplicateTaskName, ErrUnknownDependency} {
+		if !errors.Is(err, want) {
+			t.Fatalf("expected %v in validate error, got: %v", want, err)
+		}
+	}
+
+	p = New(WithSilent())
+	p.AddDependent("a", nil, func() error { return nil })
+	p.AddDependent("b", []string{"a"}, func() error { return nil })
+	if err := p.Validate(); err != nil {
+		t.Fatalf("unexpected validate error: %v", err)
+	}
+
+	l := &recordLogger{}
+	p = New(WithLogger(l))
+	if err := p.Validate(); err != nil || len(l.lines) != 1 || !strings.Contains(l.lines[0], "no tasks") {
+		t.Fatalf("expected empty runner warning, got: %v %q", err, l.lines)
+	}
+}
+
+// TestRunnerDryRun test dry run logs tasks in order without calling them
+func TestRunnerDryRun(t *testing.T) {
+	l := &recordLogger{}
+	called := false
+	p := New(WithLogger(l), WithDryRun())
+	p.AddDependent("second", []string{"first"}, func() error {
+		called = true
+		return nil
+	})
+	p.AddDependent("first", nil, func() error {
+		called = true
+		return nil
+	})
+
+	if err := p.Start(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if called {
+		t.Fatal("expected task funcs not called in dry run")
+	}
+
+	var lines []string
+	for _, line := range l.lines {
+		if strings.HasPrefix(line, "dry run task") {
+			lines = append(lines, line)
+		}
+	}
+
+	if len(lines) != 2 || !strings.Contains(lines[0], "first") || !strings.Contains(lines[1], "second") {
+		t.Fatalf("unexpected dry run logs: %v", lines)
+	}
+
+	p = New(WithSilent(), WithDryRun())
+	p.Add(nil)
+	if err := p.Start(); !errors.Is(err, ErrNilTask) {
+		t.Fatalf("expected ErrNilTask, got: %v", err)
+	}
+}
+
+// TestRunnerNilTask test nil task records ErrNilTask instead of a panic
+func TestRunnerNilTask(t *testing.T) {
+	p := New(WithSilent())
+	p.Add(nil, func() error { return nil })
+	p.AddNamed("nil", nil)
+
+	err := p.Start()
+	if !errors.Is(err, ErrNilTask) {
+		t.Fatalf("expected ErrNilTask, got: %v", err)
+	}
+
+	var pe *PanicError
+	if errors.As(err, &pe) {
+		t.Fatalf("expected friendly error instead of panic: %v", err)
+	}
+
+	errs := p.GetAllErrors()
+	if len(errs) != 2 || !errors.Is(errs[0], ErrNilTask) || !errors.Is(errs[2], ErrNilTask) {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+
+	p = New(WithSilent())
+	if err := p.AddChecked(func() error { return nil }, nil); !errors.Is(err, ErrNilTask) {
+		t.Fatalf("expected AddChecked to return ErrNilTask, got: %v", err)
+	}
+
+	if p.Len() != 0 {
+		t.Fatalf("expected no tasks added, got: %d", p.Len())
+	}
+}